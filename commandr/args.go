@@ -16,7 +16,15 @@ type CommandArgs struct {
 	CmdName string
 	Args    []string
 	FlagSet *flag.FlagSet
-	output  io.Writer
+	// Persistent holds the values of the persistent flags inherited by the executed command.
+	Persistent *flag.FlagSet
+	output     io.Writer
+
+	// persistentDefs are the persistent flags of the executed command, pathFlags the flag args given before its args
+	// and rawArgs the args before the persistent flags were taken out.
+	persistentDefs *flag.FlagSet
+	pathFlags      []string
+	rawArgs        []string
 }
 
 // String will return the CmdLine the original one that is parsed.
@@ -24,6 +32,15 @@ func (c *CommandArgs) String() string {
 	return c.CmdLine
 }
 
+// Stderr returns the writer used for diagnostics such as flag parse errors. Output written here is kept separate
+// from the normal command output written to the client.
+func (c *CommandArgs) Stderr() io.Writer {
+	if c.output == nil {
+		return io.Discard
+	}
+	return c.output
+}
+
 // PealOff will return a command line string after N commands have been pealed off from the front of the command line.
 func (c *CommandArgs) PealOff(pos int) string {
 	var buffer bytes.Buffer
@@ -58,19 +75,31 @@ func (c *CommandArgs) Debug() string {
 	return buffer.String()
 }
 
-// Parse will use the defined FlagSet parsed and return an error if help is invoked or invalid flags
+// Parse will use the defined FlagSet parsed and return an error if help is invoked or invalid flags. Flags declared in
+// the FlagSet shadow inherited persistent flags of the same name, which are parsed again into Persistent.
 func (c *CommandArgs) Parse() error {
+	if c.persistentDefs != nil {
+		if err := c.parsePersistent(); err != nil {
+			return err
+		}
+	}
 	return c.FlagSet.Parse(c.Args)
 }
 
-// Shift will return a new CommandArgs after shifting the first cmd in the string
+// Shift will return a new CommandArgs after shifting the first cmd in the string. The first arg becomes the CmdName
+// of the returned CommandArgs.
 func (c *CommandArgs) Shift() (*CommandArgs, error) {
-	if strings.HasPrefix(c.CmdLine, c.CmdName) {
-		newCmdLine := c.CmdLine[len(c.CmdName):]
-		return NewCommandArgs(newCmdLine, c.output)
+	if len(c.Args) == 0 {
+		return nil, errors.New("Unable to shift command line. ")
 	}
-	return nil, errors.New("Unable to shift command line. ")
 
+	cmdLine := strings.TrimLeft(c.CmdLine, " \t")
+	if strings.HasPrefix(cmdLine, c.CmdName) {
+		cmdLine = strings.TrimLeft(cmdLine[len(c.CmdName):], " \t")
+	} else {
+		cmdLine = shellquote.Join(c.Args...)
+	}
+	return newCommandArgs(cmdLine, c.Args[0], c.Args[1:], c.output), nil
 }
 
 // NewCommandArgs will take a raw string and output. The Raw string will be parsed into a CommandArgs structure.
@@ -90,20 +119,63 @@ func NewCommandArgs(cmdLine string, output io.Writer) (*CommandArgs, error) {
 
 	} else {
 
-		invoke := &CommandArgs{}
-		invoke.CmdLine = cmdLine
-		invoke.CmdName = words[0]
-		invoke.Args = words[1:]
-		invoke.output = output
-		invoke.FlagSet = flag.NewFlagSet(invoke.CmdName, flag.ContinueOnError)
-
-		if output != nil {
-			invoke.FlagSet.SetOutput(output)
-		}
-		return invoke, nil
+		return newCommandArgs(cmdLine, words[0], words[1:], output), nil
 	}
 	// topic Hello World Stinky
 	// topic "Hello World" Stinky
 	// group 156 topic Hello World Stinky
 
 }
+
+// NeedsContinuation returns true if cmdLine is incomplete because it ends inside a quoted string, with a
+// backslash escaping the newline or with a brace that is not closed. It can be used as the ContinuationCallback of a
+// term.Terminal so such a command is continued on the next line.
+func NeedsContinuation(cmdLine string) bool {
+	_, err := shellquote.Split(cmdLine)
+	switch err {
+	case shellquote.UnterminatedSingleQuoteError, shellquote.UnterminatedDoubleQuoteError, shellquote.UnterminatedEscapeError:
+		return true
+	}
+	return braceDepth(cmdLine) > 0
+}
+
+// braceDepth returns the number of braces in cmdLine that are opened but not closed. Quoted and escaped braces are
+// not counted.
+func braceDepth(cmdLine string) int {
+	depth := 0
+	var quote rune
+	escaped := false
+	for _, r := range cmdLine {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '{':
+			depth++
+		case r == '}' && depth > 0:
+			depth--
+		}
+	}
+	return depth
+}
+
+func newCommandArgs(cmdLine, cmdName string, args []string, output io.Writer) *CommandArgs {
+	invoke := &CommandArgs{}
+	invoke.CmdLine = cmdLine
+	invoke.CmdName = cmdName
+	invoke.Args = args
+	invoke.output = output
+	invoke.FlagSet = flag.NewFlagSet(invoke.CmdName, flag.ContinueOnError)
+
+	if output != nil {
+		invoke.FlagSet.SetOutput(output)
+	}
+	return invoke
+}
@@ -182,3 +182,33 @@ func TestCommandArgsCmdShiftParse(t *testing.T) {
 //         t.Errorf("Command Args should error on zero len string")
 //     }
 // }
+
+func TestNeedsContinuation(t *testing.T) {
+	tests := []struct {
+		cmdLine string
+		more    bool
+	}{
+		{"hello world", false},
+		{"hello \"world", true},
+		{"hello 'world", true},
+		{"hello world\\", true},
+		{"hello \"big\nworld\"", false},
+		{"hello \\\nworld", false},
+		{"if x {", true},
+		{"if x {\n  echo {a}\n", true},
+		{"if x {\n  echo hi\n}", false},
+		{"echo \"{\" '{' \\{", false},
+		{"echo }{", true},
+	}
+
+	for _, test := range tests {
+		if more := NeedsContinuation(test.cmdLine); more != test.more {
+			t.Errorf("NeedsContinuation(%q) = %v, expected %v", test.cmdLine, more, test.more)
+		}
+	}
+
+	cmdArgs, err := NewCommandArgs("hello \\\nworld", nil)
+	if err != nil || len(cmdArgs.Args) != 1 || cmdArgs.Args[0] != "world" {
+		t.Errorf("continued command line parsed to %v, %v", cmdArgs, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/alexj212/gox/utilx"
 	"github.com/go-errors/errors"
@@ -33,6 +34,28 @@ const Admin = ExecLevel(2)
 // SuperAdmin level for admin users
 const SuperAdmin = ExecLevel(255)
 
+// LeveledClient is implemented by clients that carry an ExecLevel. Commands with an ExecLevel greater than the
+// client's level are not available to that client. Clients that do not implement it can execute every command.
+type LeveledClient interface {
+	io.Writer
+	ExecLevel() ExecLevel
+}
+
+// PagingClient is implemented by clients that can page long output, such as term.Terminal. Output written between
+// StartPaging and StopPaging pauses with a --More-- prompt once it fills the screen.
+type PagingClient interface {
+	StartPaging()
+	StopPaging()
+}
+
+// CanExecute determines if the client is allowed to execute the command.
+func (c *Command) CanExecute(client io.Writer) bool {
+	if lc, ok := client.(LeveledClient); ok {
+		return lc.ExecLevel() >= c.ExecLevel
+	}
+	return true
+}
+
 func (v ExecLevel) String() string {
 	switch v {
 	case None:
@@ -80,6 +103,9 @@ type Command struct {
 	// Deprecated defines, if this command is deprecated and should print this string when used.
 	Deprecated string
 
+	// DisablePaging defines, if the output of this command should never be paged, e.g. for interactive commands.
+	DisablePaging bool
+
 	ExecLevel ExecLevel
 
 	// commands is the list of commands supported by this program.
@@ -122,6 +148,9 @@ type Command struct {
 
 	FlagSet  *flag.FlagSet
 	HasFlags bool
+
+	// persistentFlags are the flags inherited by this command and its sub commands.
+	persistentFlags *flag.FlagSet
 }
 
 // SetHelpFunc sets help function. Can be defined by Application.
@@ -161,11 +190,9 @@ func (c *Command) HasSubCommands() bool {
 
 // UseLine puts out the full usage for a given command (including parents).
 func (c *Command) UseLine() string {
-	var useline string
-	if c.HasParent() && c.Runnable() && c.parent.Runnable() {
-		useline = c.parent.Use + " " + c.Use
-	} else {
-		useline = c.Use
+	useline := c.Use
+	if c.HasParent() && c.parent.HasParent() {
+		useline = c.parent.CommandPath() + " " + c.Use
 	}
 
 	if c.HasFlags && !strings.Contains(useline, "[flags]") {
@@ -266,7 +293,7 @@ func (c *Command) UsageFunc() (f func(*Command, io.Writer) error) {
 	}
 	return func(c *Command, io io.Writer) error {
 
-		err := utilx.Tmpl(io, c.UsageTemplate(), c)
+		err := utilx.TmplFuncs(io, c.UsageTemplate(), c, c.templateFuncs(io))
 		if err != nil {
 			_, _ = io.Write([]byte(err.Error()))
 		}
@@ -292,7 +319,7 @@ func (c *Command) HelpFunc() func(*Command, []string, io.Writer) {
 	}
 	return func(c *Command, a []string, client io.Writer) {
 
-		err := utilx.Tmpl(client, c.HelpTemplate(), c)
+		err := utilx.TmplFuncs(client, c.HelpTemplate(), c, c.templateFuncs(client))
 		if err != nil {
 			_, _ = client.Write([]byte(err.Error()))
 		}
@@ -361,10 +388,13 @@ func (c *Command) UsageTemplate() string {
   {{.CommandPath}} [command]{{end}}{{if .HasExample}}
 
 Examples:
-{{.Example}}{{end}}{{if .HasAvailableSubCommands}}
+{{markdown .Example | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableSubCommands}}
 
 Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
-  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasHelpSubCommands}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
 
 Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
   {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
@@ -382,9 +412,38 @@ func (c *Command) HelpTemplate() string {
 	if c.HasParent() {
 		return c.parent.HelpTemplate()
 	}
-	return `{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces}}
+	return `{{with (or .Long .Short)}}{{markdown . | trimTrailingWhitespaces}}
+
+{{end}}{{if or .Runnable .HasSubCommands}}{{usage .}}{{end}}`
+}
+
+// helpLeftPad is the indent of markdown rendered in help output.
+const helpLeftPad = 2
 
-{{end}}{{if or .Runnable .HasSubCommands}}{{.UsageString}}{{end}}`
+// templateFuncs returns the funcs available to the help and usage templates when rendering for client. The
+// markdown func renders Markdown, such as Long and Example, with the terminal markdown renderer at the client's
+// width. Clients that do not support ANSI escape sequences get the Markdown source as plain text.
+func (c *Command) templateFuncs(client io.Writer) template.FuncMap {
+	width := ClientWidth(client)
+	ansi := ClientSupportsANSI(client)
+
+	funcs := template.FuncMap{}
+	funcs["markdown"] = func(s string) string {
+		if !ansi || s == "" {
+			return s
+		}
+		var b bytes.Buffer
+		RenderMarkdownWidth(&b, s, width, helpLeftPad)
+		return b.String()
+	}
+	funcs["usage"] = func(cmd *Command) string {
+		var b bytes.Buffer
+		if err := utilx.TmplFuncs(&b, cmd.UsageTemplate(), cmd, funcs); err != nil {
+			return fmt.Sprintf("UsageString error: %v", err)
+		}
+		return b.String()
+	}
+	return funcs
 }
 
 // VersionTemplate return version template for the command.
@@ -524,15 +583,26 @@ main:
 	}
 }
 
-// CommandPath returns the full path to this command.
+// CommandPath returns the full path to this command. The root command is never typed by the user, so it is not
+// part of the path.
 func (c *Command) CommandPath() string {
-	if c.HasParent() && c.parent.Runnable() {
+	if c.HasParent() && c.parent.HasParent() {
 		return c.Parent().CommandPath() + " " + c.Name()
 	}
 
 	return c.Name()
 }
 
+// findSubCommand returns the sub command named name that the client is allowed to execute, or nil.
+func (c *Command) findSubCommand(client io.Writer, name string) *Command {
+	for _, cmd := range c.commands {
+		if cmd.Name() == name && cmd.CanExecute(client) {
+			return cmd
+		}
+	}
+	return nil
+}
+
 // IsSubCommandAvailable checks if sub command exists
 func (c *Command) IsSubCommandAvailable(client io.Writer, cmd string) bool {
 
@@ -540,43 +610,61 @@ func (c *Command) IsSubCommandAvailable(client io.Writer, cmd string) bool {
 		return true
 	}
 
-	for _, command := range c.commands {
-		if cmd == command.Use && command.Exec != nil {
+	sub := c.findSubCommand(client, cmd)
+	return sub != nil && (sub.Runnable() || sub.HasSubCommands())
+}
 
-			// if client.ExecLevel() >= command.ExecLevel {
-			// 	return true
-			// }
-			return false
+// Find walks the command line down the command tree and returns the deepest command matching the leading words
+// along with the command line shifted to that command. Words left over after the last matching command are the
+// args of the returned command. If the first word does not match a sub command of c, nil is returned.
+func (c *Command) Find(client io.Writer, cmdLine *CommandArgs) (*Command, *CommandArgs) {
+	cmd := c.findSubCommand(client, cmdLine.CmdName)
+	if cmd == nil {
+		return nil, cmdLine
+	}
+
+	for len(cmdLine.Args) > 0 && cmdLine.Args[0] != "help" && cmd.IsSubCommandAvailable(client, cmdLine.Args[0]) {
+		shifted, err := cmdLine.Shift()
+		if err != nil {
+			break
 		}
+		cmd = cmd.findSubCommand(client, shifted.CmdName)
+		cmdLine = shifted
 	}
-	return false
+	return cmd, cmdLine
 }
 
-// Execute runs a command thru execution
-//
-//gocyclo:ignore
-func (c *Command) Execute(client io.Writer, cmdLine *CommandArgs) error {
+func (c *Command) writeSuggestions(client io.Writer, typedName string) {
+	val := c.findSuggestions(typedName)
+	if val == "" {
+		c.Help(client)
+	} else {
+		client.Write([]byte(val))
+	}
+}
 
-	if cmdLine.CmdName == "help" {
+func (c *Command) executeHelp(client io.Writer, args []string) {
+	target := c
+	for _, name := range args {
+		cmd := target.findSubCommand(client, name)
+		if cmd == nil {
+			target.writeSuggestions(client, name)
+			return
+		}
+		target = cmd
+	}
+	target.Help(client)
+}
 
-		if len(cmdLine.Args) > 0 {
-			for _, cmd := range c.commands {
-				if cmd.Name() == cmdLine.Args[0] {
-					cmd.Help(client)
-					return nil
-				}
-			}
+func isHelpArg(arg string) bool {
+	return arg == "help" || arg == "--help" || arg == "-help"
+}
 
-			val := c.findSuggestions(cmdLine.Args[0])
-			if val == "" {
-				c.Help(client)
-			} else {
-				client.Write([]byte(val))
-			}
-			return nil
-		}
+// Execute runs a command thru execution
+func (c *Command) Execute(client io.Writer, cmdLine *CommandArgs) error {
 
-		c.Help(client)
+	if cmdLine.CmdName == "help" {
+		c.executeHelp(client, cmdLine.Args)
 		return nil
 	}
 	defer func() {
@@ -590,44 +678,36 @@ func (c *Command) Execute(client io.Writer, cmdLine *CommandArgs) error {
 		}
 	}()
 
-	for _, cmd := range c.commands {
-		if cmd.Name() == cmdLine.CmdName {
-			shifted, err := cmdLine.Shift()
-			if err == nil {
-				if cmd.IsSubCommandAvailable(client, shifted.CmdName) {
-					execErr := cmd.Execute(client, shifted)
-					return execErr
-				}
+	cmdLine, flagArgs := c.extractPersistentFlags(client, cmdLine)
 
-				if cmd.Exec == nil {
-					cmd.Help(client)
-				}
-
-				return nil
-
-			}
+	cmd, cmdLine := c.Find(client, cmdLine)
+	if cmd == nil {
+		c.writeSuggestions(client, cmdLine.CmdName)
+		return nil
+	}
 
-			if len(cmdLine.Args) > 0 && (cmdLine.Args[0] == "help" || cmdLine.Args[0] == "--help" || cmdLine.Args[0] == "-help") {
-				cmd.Help(client)
-			} else {
+	if err := cmdLine.setPersistent(cmd.InheritedFlags(), flagArgs); err != nil {
+		return err
+	}
 
-				if cmd.Exec == nil {
-					cmd.Help(client)
-				} else {
-					execErr := cmd.Exec(client, cmd, cmdLine)
-					return execErr
-				}
-			}
+	if len(cmdLine.Args) > 0 && isHelpArg(cmdLine.Args[0]) {
+		cmd.executeHelp(client, cmdLine.Args[1:])
+		return nil
+	}
 
-			return nil
+	if !cmd.Runnable() {
+		if len(cmdLine.Args) > 0 && cmd.HasSubCommands() {
+			cmd.writeSuggestions(client, cmdLine.Args[0])
+		} else {
+			cmd.Help(client)
 		}
+		return nil
 	}
 
-	val := c.findSuggestions(cmdLine.CmdName)
-	if val == "" {
-		c.Help(client)
-	} else {
-		client.Write([]byte(val))
+	if pc, ok := client.(PagingClient); ok && !cmd.DisablePaging {
+		pc.StartPaging()
+		defer pc.StopPaging()
 	}
-	return nil
+
+	return cmd.Exec(client, cmd, cmdLine)
 }
@@ -0,0 +1,150 @@
+package commandr_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+)
+
+func recordExec(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+	fmt.Fprintf(client, "%s: [%s]\n", cmd.CommandPath(), strings.Join(args.Args, ","))
+	return nil
+}
+
+// threeLevelTree builds
+//
+//	group
+//	  sub
+//	    leaf
+//	  run
+//	    child
+func threeLevelTree() *commandr.Command {
+	root := &commandr.Command{ExecLevel: commandr.All}
+
+	group := &commandr.Command{Use: "group", Short: "a group", ExecLevel: commandr.All}
+	sub := &commandr.Command{Use: "sub", Short: "a sub group", ExecLevel: commandr.All}
+	leaf := &commandr.Command{Use: "leaf <name>", Short: "a leaf", Exec: recordExec, ExecLevel: commandr.All}
+	run := &commandr.Command{Use: "run", Short: "runnable parent", Exec: recordExec, ExecLevel: commandr.All}
+	child := &commandr.Command{Use: "child", Short: "child of runnable", Exec: recordExec, ExecLevel: commandr.All}
+	admin := &commandr.Command{Use: "admin", Short: "admin leaf", Exec: recordExec, ExecLevel: commandr.Admin}
+
+	sub.AddCommand(leaf, admin)
+	run.AddCommand(child)
+	group.AddCommand(sub, run)
+	root.AddCommand(group)
+	return root
+}
+
+var dispatchTests = []struct {
+	in     string
+	level  commandr.ExecLevel
+	stdout string
+}{
+	{in: "group sub leaf", stdout: "group sub leaf: []\n"},
+	{in: "group sub leaf --flag x y", stdout: "group sub leaf: [--flag,x,y]\n"},
+	{in: `group sub leaf "hello world" x`, stdout: "group sub leaf: [hello world,x]\n"},
+	{in: "group run", stdout: "group run: []\n"},
+	{in: "group run extra", stdout: "group run: [extra]\n"},
+	{in: "group run child a", stdout: "group run child: [a]\n"},
+	{in: "group sub admin", level: commandr.Admin, stdout: "group sub admin: []\n"},
+}
+
+func TestNestedDispatch(t *testing.T) {
+	for _, test := range dispatchTests {
+		level := test.level
+		if level == commandr.All {
+			level = commandr.User
+		}
+		res := commandrtest.New(threeLevelTree(), level).Run(test.in)
+		if res.Err != nil {
+			t.Errorf("%q returned error: %v", test.in, res.Err)
+		}
+		if res.Stdout != test.stdout {
+			t.Errorf("%q wrote %q, expected %q", test.in, res.Stdout, test.stdout)
+		}
+	}
+}
+
+func TestNestedDispatchExecLevel(t *testing.T) {
+	res := commandrtest.New(threeLevelTree(), commandr.User).Run("group sub admin")
+	if strings.Contains(res.Stdout, "group sub admin: ") {
+		t.Errorf("user should not be able to run admin command, got %q", res.Stdout)
+	}
+}
+
+func TestNestedHelp(t *testing.T) {
+	for _, in := range []string{"help group sub leaf", "group sub leaf --help", "group sub help leaf"} {
+		res := commandrtest.New(threeLevelTree(), commandr.User).Run(in)
+		if !strings.Contains(res.Stdout, "Usage:\n  group sub leaf <name>\n") {
+			t.Errorf("%q help did not contain full use line:\n%s", in, res.Stdout)
+		}
+	}
+
+	res := commandrtest.New(threeLevelTree(), commandr.User).Run("group sub")
+	if !strings.Contains(res.Stdout, "group sub [command]") {
+		t.Errorf("group help did not contain full command path:\n%s", res.Stdout)
+	}
+	if !strings.Contains(res.Stdout, "leaf") {
+		t.Errorf("group help did not list sub commands:\n%s", res.Stdout)
+	}
+}
+
+func TestNestedSuggestions(t *testing.T) {
+	res := commandrtest.New(threeLevelTree(), commandr.User).Run("group sub lef")
+	if !strings.Contains(res.Stdout, "Did you mean this?") || !strings.Contains(res.Stdout, "leaf") {
+		t.Errorf("expected suggestion for leaf, got:\n%s", res.Stdout)
+	}
+}
+
+// subCommand returns the sub command of c with the given name, or nil.
+func subCommand(c *commandr.Command, name string) *commandr.Command {
+	for _, sub := range c.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func TestCommandPath(t *testing.T) {
+	root := threeLevelTree()
+	leaf := subCommand(subCommand(subCommand(root, "group"), "sub"), "leaf")
+	if leaf.CommandPath() != "group sub leaf" {
+		t.Errorf("CommandPath was %q", leaf.CommandPath())
+	}
+	if leaf.UseLine() != "group sub leaf <name>" {
+		t.Errorf("UseLine was %q", leaf.UseLine())
+	}
+}
+
+type pagingClient struct {
+	strings.Builder
+	started, stopped int
+}
+
+func (c *pagingClient) StartPaging() { c.started++ }
+func (c *pagingClient) StopPaging()  { c.stopped++ }
+
+func TestExecutePaging(t *testing.T) {
+	root := &commandr.Command{ExecLevel: commandr.All}
+	root.AddCommand(&commandr.Command{Use: "long", Exec: recordExec, ExecLevel: commandr.All})
+	root.AddCommand(&commandr.Command{Use: "interactive", Exec: recordExec, ExecLevel: commandr.All, DisablePaging: true})
+
+	client := &pagingClient{}
+	args, _ := commandr.NewCommandArgs("long", nil)
+	root.Execute(client, args)
+	if client.started != 1 || client.stopped != 1 {
+		t.Errorf("paging should be started and stopped once, got %d/%d", client.started, client.stopped)
+	}
+
+	client = &pagingClient{}
+	args, _ = commandr.NewCommandArgs("interactive", nil)
+	root.Execute(client, args)
+	if client.started != 0 {
+		t.Errorf("paging should not be started for commands with DisablePaging")
+	}
+}
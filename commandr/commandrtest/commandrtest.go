@@ -0,0 +1,177 @@
+// Package commandrtest provides utilities for testing commandr commands.
+//
+// A Harness runs a command line against a command tree as a client with a given ExecLevel and captures what the
+// command writes to the client (stdout) separately from diagnostics such as flag errors (stderr):
+//
+//	h := commandrtest.New(root, commandr.Admin)
+//	res := h.Input("yes").Run("deploy --env prod")
+//	commandrtest.Golden(t, "deploy", res.Stdout)
+//
+// Golden files are stored in testdata/<name>.golden and are rewritten when the tests are run with -update.
+package commandrtest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// ErrNoMoreInput is returned to a command prompting for input after all scripted input lines have been consumed.
+var ErrNoMoreInput = errors.New("commandrtest: no more scripted input")
+
+// Harness runs command lines against a command tree.
+type Harness struct {
+	// Root is the command tree the command lines are executed against.
+	Root *commandr.Command
+
+	// Level is the ExecLevel of the client running the commands.
+	Level commandr.ExecLevel
+
+	// Width and Height are the terminal size reported by the client. Zero values are reported as 80x24.
+	Width, Height int
+
+	// ANSI defines, if the client reports that it supports ANSI escape sequences.
+	ANSI bool
+
+	input []string
+}
+
+// Result contains the captured output of a command run.
+type Result struct {
+	// Stdout is the output written to the client with ANSI escape sequences removed.
+	Stdout string
+	// Stderr is the diagnostic output such as flag parse errors with ANSI escape sequences removed.
+	Stderr string
+	// RawStdout is the output written to the client as is.
+	RawStdout string
+	// RawStderr is the diagnostic output as is.
+	RawStderr string
+	// Err is the error returned from parsing or executing the command line.
+	Err error
+}
+
+// New returns a Harness that runs commands in root as a client with the given ExecLevel.
+func New(root *commandr.Command, level commandr.ExecLevel) *Harness {
+	return &Harness{Root: root, Level: level}
+}
+
+// Input scripts lines of input that are returned, in order, to commands prompting the user with commandr.Prompt.
+func (h *Harness) Input(lines ...string) *Harness {
+	h.input = append(h.input, lines...)
+	return h
+}
+
+// Run executes the command line and returns the captured output. Scripted input that was not consumed by the
+// command is kept for the next run.
+func (h *Harness) Run(cmdLine string) *Result {
+	client := &Client{Level: h.Level, Width: h.Width, Height: h.Height, ANSI: h.ANSI, Input: h.input}
+	stderr := new(bytes.Buffer)
+
+	res := &Result{}
+	args, err := commandr.NewCommandArgs(cmdLine, stderr)
+	if err == nil {
+		err = h.Root.Execute(client, args)
+	}
+	h.input = client.Input
+
+	res.Err = err
+	res.RawStdout = client.Stdout.String()
+	res.RawStderr = stderr.String()
+	res.Stdout = StripANSI(res.RawStdout)
+	res.Stderr = StripANSI(res.RawStderr)
+	return res
+}
+
+// Client is a commandr client that records what is written to it and answers prompts from scripted input.
+type Client struct {
+	// Level is the ExecLevel reported by the client.
+	Level commandr.ExecLevel
+	// Width and Height are the terminal size reported by the client.
+	Width, Height int
+	// ANSI defines, if the client reports that it supports ANSI escape sequences.
+	ANSI bool
+	// Input contains the remaining scripted input lines.
+	Input []string
+	// Stdout contains everything written to the client, including prompts and the echoed input.
+	Stdout bytes.Buffer
+}
+
+// Write records p.
+func (c *Client) Write(p []byte) (int, error) {
+	return c.Stdout.Write(p)
+}
+
+// ExecLevel returns the level of the client.
+func (c *Client) ExecLevel() commandr.ExecLevel {
+	return c.Level
+}
+
+// Size returns the terminal size of the client, defaulting to 80x24.
+func (c *Client) Size() (width, height int) {
+	width, height = c.Width, c.Height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return
+}
+
+// SupportsANSI returns true if the client was configured to support ANSI escape sequences.
+func (c *Client) SupportsANSI() bool {
+	return c.ANSI
+}
+
+// Prompt writes the prompt and returns the next scripted input line, echoing it like a terminal would.
+func (c *Client) Prompt(prompt string) (string, error) {
+	c.Stdout.WriteString(prompt)
+	if len(c.Input) == 0 {
+		c.Stdout.WriteString("\n")
+		return "", ErrNoMoreInput
+	}
+	line := c.Input[0]
+	c.Input = c.Input[1:]
+	c.Stdout.WriteString(line + "\n")
+	return line, nil
+}
+
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;?<=>]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI removes ANSI escape sequences from s.
+func StripANSI(s string) string {
+	return ansiRegexp.ReplaceAllString(s, "")
+}
+
+// Golden compares got with the contents of testdata/<name>.golden. When the tests are run with -update the golden
+// file is written instead.
+func Golden(t testing.TB, name string, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("unable to update golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file %s: %v (run with -update to create it)", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("output does not match golden file %s\n--- got:\n%s\n--- want:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package commandrtest_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+	"github.com/fatih/color"
+)
+
+func testTree() *commandr.Command {
+	root := &commandr.Command{ExecLevel: commandr.All}
+	root.AddCommand(&commandr.Command{
+		Use:       "greet",
+		Short:     "greet someone",
+		ExecLevel: commandr.All,
+		Exec: func(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+			client.Write([]byte(color.New(color.FgGreen).Sprint("hello world\n")))
+			return nil
+		},
+	})
+	root.AddCommand(&commandr.Command{
+		Use:       "fail",
+		Short:     "report an error",
+		ExecLevel: commandr.All,
+		Exec: func(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+			fmt.Fprintln(args.Stderr(), "something went wrong")
+			return errors.New("failed")
+		},
+	})
+	root.AddCommand(&commandr.Command{
+		Use:       "ask",
+		Short:     "ask a question",
+		ExecLevel: commandr.All,
+		Exec: func(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+			answer, err := commandr.Prompt(client, "continue? ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(client, "answer: %s\n", answer)
+			return nil
+		},
+	})
+	root.AddCommand(&commandr.Command{
+		Use:       "secret",
+		Short:     "admin only",
+		ExecLevel: commandr.Admin,
+		Exec: func(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+			fmt.Fprintln(client, "secret")
+			return nil
+		},
+	})
+	return root
+}
+
+func TestRunCapturesStdout(t *testing.T) {
+	color.NoColor = false
+	defer func() { color.NoColor = true }()
+
+	res := commandrtest.New(testTree(), commandr.User).Run("greet")
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if res.Stdout != "hello world\n" {
+		t.Errorf("stdout was %q", res.Stdout)
+	}
+	if res.RawStdout == res.Stdout {
+		t.Errorf("raw stdout should contain escape sequences, got %q", res.RawStdout)
+	}
+}
+
+func TestRunCapturesStderr(t *testing.T) {
+	res := commandrtest.New(testTree(), commandr.User).Run("fail")
+	if res.Err == nil || res.Err.Error() != "failed" {
+		t.Fatalf("expected error, got %v", res.Err)
+	}
+	if res.Stdout != "" {
+		t.Errorf("stdout should be empty, got %q", res.Stdout)
+	}
+	if res.Stderr != "something went wrong\n" {
+		t.Errorf("stderr was %q", res.Stderr)
+	}
+}
+
+func TestRunScriptedInput(t *testing.T) {
+	h := commandrtest.New(testTree(), commandr.User).Input("yes", "no")
+	commandrtest.Golden(t, "ask", h.Run("ask").Stdout)
+
+	res := h.Run("ask")
+	if res.Stdout != "continue? no\nanswer: no\n" {
+		t.Errorf("second run stdout was %q", res.Stdout)
+	}
+
+	res = h.Run("ask")
+	if res.Err != commandrtest.ErrNoMoreInput {
+		t.Errorf("expected ErrNoMoreInput, got %v", res.Err)
+	}
+}
+
+func TestRunExecLevel(t *testing.T) {
+	res := commandrtest.New(testTree(), commandr.User).Run("secret")
+	if res.Stdout == "secret\n" {
+		t.Errorf("user level client should not be able to run admin command")
+	}
+
+	res = commandrtest.New(testTree(), commandr.Admin).Run("secret")
+	if res.Stdout != "secret\n" {
+		t.Errorf("admin level client should run admin command, got %q", res.Stdout)
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	in := "\x1b[32mgreen\x1b[0m \x1b]8;;http://x\x07link\x1b]8;;\x07 \x1b[2K"
+	if got := commandrtest.StripANSI(in); got != "green link " {
+		t.Errorf("StripANSI returned %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package commandr
+
+import (
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/alexj212/gox/term"
+)
+
+// Completer returns a function to use as the CompleteCallback of a term.Terminal. It completes the names of the
+// commands available to client, described by their Short text, and the persistent flags of the command being typed.
+func (c *Command) Completer(client io.Writer) func(line string, pos int) (start int, candidates []term.Completion) {
+	return func(line string, pos int) (int, []term.Completion) {
+		words := splitWords(line[:pos])
+		prefix := ""
+		if len(words) > 0 && !words[len(words)-1].space {
+			prefix = words[len(words)-1].text
+			words = words[:len(words)-1]
+		}
+
+		cmd := c
+		for _, w := range words {
+			if w.space || strings.HasPrefix(w.text, "-") {
+				continue
+			}
+			sub := cmd.findSubCommand(client, w.text)
+			if sub == nil {
+				// The args of cmd have been started.
+				return pos, nil
+			}
+			cmd = sub
+		}
+
+		var candidates []term.Completion
+		if strings.HasPrefix(prefix, "-") {
+			cmd.InheritedFlags().VisitAll(func(f *flag.Flag) {
+				if name := "--" + f.Name; strings.HasPrefix(name, prefix) {
+					candidates = append(candidates, term.Completion{Text: name, Description: f.Usage})
+				}
+			})
+			return pos - len(prefix), candidates
+		}
+
+		for _, sub := range cmd.Commands() {
+			if sub.Hidden || !sub.CanExecute(client) || !strings.HasPrefix(sub.Name(), prefix) {
+				continue
+			}
+			candidates = append(candidates, term.Completion{Text: sub.Name(), Description: sub.Short})
+		}
+		return pos - len(prefix), candidates
+	}
+}
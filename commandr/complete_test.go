@@ -0,0 +1,45 @@
+package commandr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+)
+
+var completeTests = []struct {
+	in         string
+	level      commandr.ExecLevel
+	start      int
+	candidates string
+}{
+	{in: "", start: 0, candidates: "group=a group"},
+	{in: "gr", start: 0, candidates: "group=a group"},
+	{in: "group ", start: 6, candidates: "run=runnable parent,sub=a sub group"},
+	{in: "group s", start: 6, candidates: "sub=a sub group"},
+	{in: "group sub ", start: 10, candidates: "flags=print flags,leaf=a leaf"},
+	{in: "group sub ", level: commandr.Admin, start: 10, candidates: "admin=admin leaf,flags=print flags,leaf=a leaf"},
+	{in: "group sub leaf ", start: 15, candidates: ""},
+	{in: "group -", start: 6, candidates: "--count=number of items,--output=output format,--verbose=verbose output"},
+	{in: "group sub --v", start: 10, candidates: "--verbose=verbose output"},
+}
+
+func TestCompleter(t *testing.T) {
+	for _, test := range completeTests {
+		level := test.level
+		if level == commandr.All {
+			level = commandr.User
+		}
+		client := &commandrtest.Client{Level: level}
+		start, candidates := persistentTree().Completer(client)(test.in, len(test.in))
+
+		var got []string
+		for _, c := range candidates {
+			got = append(got, c.Text+"="+c.Description)
+		}
+		if start != test.start || strings.Join(got, ",") != test.candidates {
+			t.Errorf("completion of %q was %d %q, expected %d %q", test.in, start, strings.Join(got, ","), test.start, test.candidates)
+		}
+	}
+}
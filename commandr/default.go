@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"io"
 
+	"github.com/alexj212/gox/term"
 	"github.com/fatih/color"
 )
 
@@ -17,9 +18,17 @@ var ClsCommand = &Command{Use: "cls", Exec: clsCmd, Short: "send cls event to te
 // ExitCommand command to exit
 var ExitCommand = &Command{Use: "exit", Exec: exitCmd, Short: "exit the session", ExecLevel: All}
 
+// PagerCommand command to turn paging of long output on or off for the session
+var PagerCommand = &Command{Use: "pager [on|off]", Exec: pagerCmd, Short: "turn paging of long output on or off", ExecLevel: All, DisablePaging: true}
+
+// EditModeCommand command to switch the line editing key bindings for the session
+var EditModeCommand = &Command{Use: "editmode [emacs|vi]", Exec: editModeCmd, Short: "switch line editing between emacs and vi key bindings", ExecLevel: All}
+
 func init() {
 	DefaultCommands.AddCommand(ClsCommand)
 	DefaultCommands.AddCommand(ExitCommand)
+	DefaultCommands.AddCommand(PagerCommand)
+	DefaultCommands.AddCommand(EditModeCommand)
 	return
 }
 
@@ -78,3 +87,57 @@ func clsCmd(client io.Writer, cmd *Command, _ *CommandArgs) (err error) {
 	client.Write([]byte(("\033c")))
 	return
 }
+
+func pagerCmd(client io.Writer, cmd *Command, args *CommandArgs) (err error) {
+	pc, ok := client.(interface {
+		SetPaging(on bool)
+		Paging() bool
+	})
+	if !ok {
+		client.Write([]byte(color.RedString("paging is not supported by this client\n")))
+		return
+	}
+
+	if len(args.Args) > 0 {
+		switch args.Args[0] {
+		case "on":
+			pc.SetPaging(true)
+		case "off":
+			pc.SetPaging(false)
+		default:
+			return cmd.Usage(client)
+		}
+	}
+
+	state := "off"
+	if pc.Paging() {
+		state = "on"
+	}
+	client.Write([]byte(color.GreenString("paging is %s\n", state)))
+	return
+}
+
+func editModeCmd(client io.Writer, cmd *Command, args *CommandArgs) (err error) {
+	ec, ok := client.(interface {
+		SetEditMode(mode term.EditMode)
+		EditMode() term.EditMode
+	})
+	if !ok {
+		client.Write([]byte(color.RedString("edit modes are not supported by this client\n")))
+		return
+	}
+
+	if len(args.Args) > 0 {
+		switch args.Args[0] {
+		case "emacs":
+			ec.SetEditMode(term.EmacsMode)
+		case "vi":
+			ec.SetEditMode(term.ViMode)
+		default:
+			return cmd.Usage(client)
+		}
+	}
+
+	client.Write([]byte(color.GreenString("edit mode is %s\n", ec.EditMode())))
+	return
+}
@@ -0,0 +1,256 @@
+package commandr
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// PersistentFlags returns the flags that are defined on this command and inherited by all of its sub commands. The
+// flags defined here only describe the name, type, default and usage of each flag; the values are parsed for every
+// invocation into CommandArgs.Persistent, wherever the flags appear in the command line.
+func (c *Command) PersistentFlags() *flag.FlagSet {
+	if c.persistentFlags == nil {
+		c.persistentFlags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	}
+	return c.persistentFlags
+}
+
+// InheritedFlags returns the persistent flags of this command and all of its parents. If a flag is defined more
+// than once the definition closest to this command is used.
+func (c *Command) InheritedFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.persistentFlags == nil {
+			continue
+		}
+		cmd.persistentFlags.VisitAll(func(f *flag.Flag) {
+			if fs.Lookup(f.Name) == nil {
+				fs.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	}
+	return fs
+}
+
+// HasInheritedFlags determines if the command accepts any persistent flags.
+func (c *Command) HasInheritedFlags() bool {
+	has := false
+	c.InheritedFlags().VisitAll(func(*flag.Flag) { has = true })
+	return has
+}
+
+// InheritedFlagUsages returns the usage of the persistent flags accepted by this command.
+func (c *Command) InheritedFlagUsages() string {
+	var b bytes.Buffer
+	fs := c.InheritedFlags()
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	return b.String()
+}
+
+// extractPersistentFlags removes the persistent flags given before the args of the command from the command line.
+// Persistent flags are recognized while walking down the command tree, so flags defined on a command may appear
+// anywhere after the command's name. The args, the words after the name of the last command, are left as they are:
+// flags the command declares in its FlagSet shadow persistent flags of the same name, so the args are split once the
+// command is known, see CommandArgs.parsePersistent. The cleaned command line and the removed flag args are returned.
+func (c *Command) extractPersistentFlags(client io.Writer, cmdLine *CommandArgs) (*CommandArgs, []string) {
+	words := append([]string{cmdLine.CmdName}, cmdLine.Args...)
+
+	var flagArgs, path []string
+	// args is the index of the first word after the last command name and pathFlags the number of flag args given
+	// before it.
+	args, pathFlags := 0, 0
+
+	cmd := c
+	known := cmd.InheritedFlags()
+	for i := 0; i < len(words) && words[i] != "--"; i++ {
+		if f, err := persistentFlagAt(words, i, known); err != nil {
+			break
+		} else if f != nil {
+			flagArgs = append(flagArgs, f...)
+			i += len(f) - 1
+			continue
+		}
+
+		sub := cmd.findSubCommand(client, words[i])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		known = cmd.InheritedFlags()
+		path = append(path, words[i])
+		args, pathFlags = i+1, len(flagArgs)
+	}
+
+	if pathFlags == 0 {
+		return cmdLine, nil
+	}
+	remaining := append(path, words[args:]...)
+	return newCommandArgs(shellquote.Join(remaining...), remaining[0], remaining[1:], cmdLine.output), flagArgs[:pathFlags]
+}
+
+// persistentFlagAt returns the flag args of the flag defined in defs at words[i], which are the flag and its value
+// if it is not given with the flag. It returns nil if words[i] is not such a flag.
+func persistentFlagAt(words []string, i int, defs *flag.FlagSet) ([]string, error) {
+	name, hasValue, ok := flagName(words[i])
+	if !ok {
+		return nil, nil
+	}
+	f := defs.Lookup(name)
+	if f == nil {
+		return nil, nil
+	}
+	flagArgs := []string{"-" + strings.TrimLeft(words[i], "-")}
+	if !hasValue && !isBoolFlag(f) {
+		if i+1 >= len(words) {
+			return nil, fmt.Errorf("flag needs an argument: -%s", name)
+		}
+		flagArgs = append(flagArgs, words[i+1])
+	}
+	return flagArgs, nil
+}
+
+// splitFlags takes the flags defined in defs out of the args, except for the ones defined in local as well. Words
+// after "--" are args.
+func splitFlags(args []string, defs, local *flag.FlagSet) (remaining, flagArgs []string, err error) {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			return append(remaining, args[i:]...), flagArgs, nil
+		}
+		if name, _, ok := flagName(args[i]); !ok || local == nil || local.Lookup(name) == nil {
+			f, err := persistentFlagAt(args, i, defs)
+			if err != nil {
+				return nil, nil, err
+			}
+			if f != nil {
+				flagArgs = append(flagArgs, f...)
+				i += len(f) - 1
+				continue
+			}
+		}
+		remaining = append(remaining, args[i])
+	}
+	return remaining, flagArgs, nil
+}
+
+// flagName returns the name of the flag in arg, if arg looks like a flag.
+func flagName(arg string) (name string, hasValue bool, ok bool) {
+	if len(arg) < 2 || arg[0] != '-' {
+		return "", false, false
+	}
+	name = strings.TrimPrefix(arg[1:], "-")
+	if name == "" || name[0] == '-' || name[0] == '=' {
+		return "", false, false
+	}
+	if i := strings.Index(name, "="); i >= 0 {
+		return name[:i], true, true
+	}
+	return name, false, true
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// setPersistent keeps the persistent flags the command accepts, defs, and the flag args given before its args, and
+// parses them along with the persistent flags in the args.
+func (c *CommandArgs) setPersistent(defs *flag.FlagSet, flagArgs []string) error {
+	c.persistentDefs, c.pathFlags, c.rawArgs = defs, flagArgs, c.Args
+	return c.parsePersistent()
+}
+
+// parsePersistent takes the persistent flags out of the args and parses them, along with the ones given before the
+// args, into a new FlagSet holding the values for this invocation. Flags declared in the FlagSet are left in the args,
+// as they shadow persistent flags of the same name. Parse calls it again once the command has declared its flags.
+func (c *CommandArgs) parsePersistent() error {
+	args, flagArgs, err := splitFlags(c.rawArgs, c.persistentDefs, c.FlagSet)
+	if err != nil {
+		fmt.Fprintln(c.Stderr(), err)
+		return err
+	}
+	c.Args = args
+
+	fs := flag.NewFlagSet(c.CmdName, flag.ContinueOnError)
+	fs.SetOutput(c.Stderr())
+	c.persistentDefs.VisitAll(func(f *flag.Flag) {
+		fs.Var(cloneValue(f.Value), f.Name, f.Usage)
+	})
+	c.Persistent = fs
+	return fs.Parse(append(c.pathFlags[:len(c.pathFlags):len(c.pathFlags)], flagArgs...))
+}
+
+// cloneValue returns a copy of v holding the same value, so the values parsed for an invocation don't change the
+// flag definition. Values that are not pointers are copied into a string flag.
+func cloneValue(v flag.Value) flag.Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		s := v.String()
+		return (*stringValue)(&s)
+	}
+	clone := reflect.New(rv.Elem().Type())
+	clone.Elem().Set(rv.Elem())
+	return clone.Interface().(flag.Value)
+}
+
+type stringValue string
+
+func (s *stringValue) Set(v string) error {
+	*s = stringValue(v)
+	return nil
+}
+
+func (s *stringValue) Get() interface{} { return string(*s) }
+
+func (s *stringValue) String() string { return string(*s) }
+
+// lookup returns the value of the named flag, looking in the command's FlagSet first, as local flags shadow persistent
+// flags of the same name, and then in the persistent flags.
+func (c *CommandArgs) lookup(name string) interface{} {
+	for _, fs := range []*flag.FlagSet{c.FlagSet, c.Persistent} {
+		if fs == nil {
+			continue
+		}
+		if f := fs.Lookup(name); f != nil {
+			if getter, ok := f.Value.(flag.Getter); ok {
+				return getter.Get()
+			}
+			return f.Value.String()
+		}
+	}
+	return nil
+}
+
+// GetString returns the value of the named persistent or local flag as a string.
+func (c *CommandArgs) GetString(name string) string {
+	v := c.lookup(name)
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// GetBool returns the value of the named persistent or local bool flag.
+func (c *CommandArgs) GetBool(name string) bool {
+	v, _ := c.lookup(name).(bool)
+	return v
+}
+
+// GetInt returns the value of the named persistent or local int flag.
+func (c *CommandArgs) GetInt(name string) int {
+	v, _ := c.lookup(name).(int)
+	return v
+}
+
+// GetDuration returns the value of the named persistent or local duration flag.
+func (c *CommandArgs) GetDuration(name string) time.Duration {
+	v, _ := c.lookup(name).(time.Duration)
+	return v
+}
@@ -0,0 +1,145 @@
+package commandr_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+)
+
+func flagsExec(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+	fmt.Fprintf(client, "%s verbose=%v output=%s count=%d args=[%s]\n", cmd.CommandPath(),
+		args.GetBool("verbose"), args.GetString("output"), args.GetInt("count"), strings.Join(args.Args, ","))
+	return nil
+}
+
+func persistentTree() *commandr.Command {
+	root := threeLevelTree()
+	root.PersistentFlags().Bool("verbose", false, "verbose output")
+	root.PersistentFlags().String("output", "text", "output format")
+
+	group := root.Commands()[0]
+	group.PersistentFlags().Int("count", 1, "number of items")
+
+	leaf := &commandr.Command{Use: "flags", Short: "print flags", Exec: flagsExec, ExecLevel: commandr.All}
+	group.Commands()[1].AddCommand(leaf)
+	return root
+}
+
+var persistentFlagTests = []struct {
+	in     string
+	stdout string
+}{
+	{in: "group sub flags", stdout: "group sub flags verbose=false output=text count=1 args=[]\n"},
+	{in: "group sub flags --verbose", stdout: "group sub flags verbose=true output=text count=1 args=[]\n"},
+	{in: "--verbose group sub flags a", stdout: "group sub flags verbose=true output=text count=1 args=[a]\n"},
+	{in: "group --output json sub flags", stdout: "group sub flags verbose=false output=json count=1 args=[]\n"},
+	{in: "group sub flags a --count=3 b -output yaml", stdout: "group sub flags verbose=false output=yaml count=3 args=[a,b]\n"},
+	{in: "group sub flags -- --verbose", stdout: "group sub flags verbose=false output=text count=1 args=[--,--verbose]\n"},
+}
+
+func TestPersistentFlags(t *testing.T) {
+	for _, test := range persistentFlagTests {
+		res := commandrtest.New(persistentTree(), commandr.User).Run(test.in)
+		if res.Err != nil {
+			t.Errorf("%q returned error: %v", test.in, res.Err)
+		}
+		if res.Stdout != test.stdout {
+			t.Errorf("%q wrote %q, expected %q", test.in, res.Stdout, test.stdout)
+		}
+	}
+}
+
+func TestPersistentFlagErrors(t *testing.T) {
+	res := commandrtest.New(persistentTree(), commandr.User).Run("group sub flags --count")
+	if res.Err == nil || !strings.Contains(res.Stderr, "count") {
+		t.Errorf("expected missing argument error, got %v stderr %q", res.Err, res.Stderr)
+	}
+
+	res = commandrtest.New(persistentTree(), commandr.User).Run("group sub flags --count abc")
+	if res.Err == nil || !strings.Contains(res.Stderr, "invalid value") {
+		t.Errorf("expected invalid value error, got %v stderr %q", res.Err, res.Stderr)
+	}
+}
+
+func TestLocalFlagShadowsPersistentFlag(t *testing.T) {
+	root := persistentTree()
+	leaf := &commandr.Command{Use: "local", ExecLevel: commandr.All, HasFlags: true}
+	leaf.Exec = func(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+		output := args.FlagSet.String("output", "table", "local output format")
+		count := args.FlagSet.Int("count", 1, "local count")
+		if err := args.Parse(); err != nil {
+			return err
+		}
+		fmt.Fprintf(client, "output=%s count=%d global=%s verbose=%v args=%v\n", *output, *count,
+			args.Persistent.Lookup("output").Value, args.GetBool("verbose"), args.FlagSet.Args())
+		return nil
+	}
+	subCommand(root, "group").AddCommand(leaf)
+
+	tests := []struct {
+		cmdLine  string
+		expected string
+	}{
+		{"--output json group local -output csv -verbose", "output=csv count=1 global=json verbose=true args=[]\n"},
+		{"group --count 3 local -count 5 x", "output=table count=5 global=text verbose=false args=[x]\n"},
+		{"group local -verbose -- -output", "output=table count=1 global=text verbose=true args=[-output]\n"},
+	}
+	for _, tt := range tests {
+		res := commandrtest.New(root, commandr.User).Run(tt.cmdLine)
+		if res.Stdout != tt.expected {
+			t.Errorf("%q wrote %q, expected %q (stderr %q)", tt.cmdLine, res.Stdout, tt.expected, res.Stderr)
+		}
+	}
+}
+
+func TestPersistentFlagsHelp(t *testing.T) {
+	res := commandrtest.New(persistentTree(), commandr.User).Run("help group sub flags")
+	if !strings.Contains(res.Stdout, "Global Flags:") {
+		t.Fatalf("help did not list global flags:\n%s", res.Stdout)
+	}
+	for _, name := range []string{"-verbose", "-output", "-count"} {
+		if !strings.Contains(res.Stdout, name) {
+			t.Errorf("help did not list %s:\n%s", name, res.Stdout)
+		}
+	}
+}
+
+// switchValue is a bool flag.Value that is not a flag.Getter.
+type switchValue struct{ on bool }
+
+func (s *switchValue) String() string   { return fmt.Sprint(s != nil && s.on) }
+func (s *switchValue) IsBoolFlag() bool { return true }
+func (s *switchValue) Set(v string) error {
+	s.on = v == "true"
+	return nil
+}
+
+func TestCustomPersistentFlag(t *testing.T) {
+	root := persistentTree()
+	debug := &switchValue{}
+	root.PersistentFlags().Var(debug, "debug", "debug output")
+	leaf := &commandr.Command{Use: "debug", ExecLevel: commandr.All}
+	leaf.Exec = func(client io.Writer, cmd *commandr.Command, args *commandr.CommandArgs) error {
+		fmt.Fprintf(client, "debug=%s args=[%s]\n", args.GetString("debug"), strings.Join(args.Args, ","))
+		return nil
+	}
+	root.AddCommand(leaf)
+
+	for _, test := range []struct{ in, stdout string }{
+		{in: "--debug debug a", stdout: "debug=true args=[a]\n"},
+		{in: "debug a -debug", stdout: "debug=true args=[a]\n"},
+		{in: "debug a", stdout: "debug=false args=[a]\n"},
+	} {
+		res := commandrtest.New(root, commandr.User).Run(test.in)
+		if res.Err != nil || res.Stdout != test.stdout {
+			t.Errorf("%q wrote %q, %v, expected %q (stderr %q)", test.in, res.Stdout, res.Err, test.stdout, res.Stderr)
+		}
+	}
+	if debug.on {
+		t.Errorf("parsing changed the flag definition")
+	}
+}
@@ -0,0 +1,55 @@
+package commandr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+)
+
+func markdownTree() *commandr.Command {
+	root := &commandr.Command{ExecLevel: commandr.All}
+	root.AddCommand(&commandr.Command{
+		Use:   "deploy",
+		Short: "deploy the app",
+		Long: "Deploys the **application** to the selected environment. " +
+			"The deployment is rolled back automatically when the health checks fail.",
+		Example:   "    deploy --env prod",
+		Exec:      recordExec,
+		ExecLevel: commandr.All,
+	})
+	return root
+}
+
+func TestHelpMarkdownPlain(t *testing.T) {
+	res := commandrtest.New(markdownTree(), commandr.User).Run("help deploy")
+	if !strings.Contains(res.RawStdout, "Deploys the **application** to") {
+		t.Errorf("plain help should contain the markdown source:\n%s", res.RawStdout)
+	}
+	if res.RawStdout != res.Stdout {
+		t.Errorf("plain help should not contain escape sequences:\n%q", res.RawStdout)
+	}
+}
+
+func TestHelpMarkdownANSI(t *testing.T) {
+	h := commandrtest.New(markdownTree(), commandr.User)
+	h.ANSI = true
+	h.Width = 40
+	res := h.Run("help deploy")
+
+	if res.RawStdout == res.Stdout {
+		t.Errorf("markdown help should contain escape sequences:\n%q", res.RawStdout)
+	}
+	if strings.Contains(res.Stdout, "**") {
+		t.Errorf("markdown help should render emphasis:\n%s", res.Stdout)
+	}
+	if !strings.Contains(res.Stdout, "deploy --env prod") {
+		t.Errorf("markdown help should contain the example:\n%s", res.Stdout)
+	}
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		if len([]rune(line)) > 40 {
+			t.Errorf("line exceeds client width: %q", line)
+		}
+	}
+}
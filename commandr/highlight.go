@@ -0,0 +1,96 @@
+package commandr
+
+import (
+	"io"
+	"strings"
+
+	"github.com/alexj212/gox/term"
+)
+
+// Highlighter returns a function to use as the Highlighter of a term.Terminal. It colors command names that resolve
+// for client green and those that don't red, flags yellow and quoted strings cyan, using the escape codes of the
+// terminal.
+func (c *Command) Highlighter(client io.Writer, escape *term.EscapeCodes) func(line string) []term.Segment {
+	return func(line string) []term.Segment {
+		var segments []term.Segment
+		cmd := c
+		resolving := true
+
+		for _, w := range splitWords(line) {
+			segment := term.Segment{Text: w.text}
+			switch {
+			case w.space:
+			case resolving && w.text == "help":
+				segment.Style = escape.Green
+			case resolving && cmd.findSubCommand(client, w.text) != nil:
+				cmd = cmd.findSubCommand(client, w.text)
+				resolving = cmd.HasSubCommands()
+				segment.Style = escape.Green
+			case resolving && cmd == c:
+				resolving = false
+				segment.Style = escape.Red
+			case w.quoted:
+				resolving = false
+				segment.Style = escape.Cyan
+			case strings.HasPrefix(w.text, "-"):
+				segment.Style = escape.Yellow
+			default:
+				resolving = false
+			}
+			segments = append(segments, segment)
+		}
+		return segments
+	}
+}
+
+// word is a piece of a command line split by splitWords.
+type word struct {
+	text   string
+	space  bool
+	quoted bool
+}
+
+// splitWords splits line into words and the white space between them, keeping quoted strings and escaped
+// characters in their word. Joining the text of the words gives line.
+func splitWords(line string) []word {
+	var words []word
+	runes := []rune(line)
+
+	for start := 0; start < len(runes); {
+		end := start
+		if isSpace(runes[start]) {
+			for end < len(runes) && isSpace(runes[end]) {
+				end++
+			}
+			words = append(words, word{text: string(runes[start:end]), space: true})
+			start = end
+			continue
+		}
+
+		quoted := false
+		var quote rune
+		for end < len(runes) && (quote != 0 || !isSpace(runes[end])) {
+			r := runes[end]
+			switch {
+			case r == '\\' && quote != '\'':
+				end++
+			case quote != 0 && r == quote:
+				quote = 0
+			case quote == 0 && (r == '"' || r == '\''):
+				quote = r
+				quoted = true
+			}
+			end++
+		}
+		if end > len(runes) {
+			end = len(runes)
+		}
+		words = append(words, word{text: string(runes[start:end]), quoted: quoted})
+		start = end
+	}
+	return words
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n'
+}
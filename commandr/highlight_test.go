@@ -0,0 +1,55 @@
+package commandr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+	"github.com/alexj212/gox/term"
+)
+
+var markerEscapes = &term.EscapeCodes{
+	Red:    []byte("R:"),
+	Green:  []byte("G:"),
+	Yellow: []byte("Y:"),
+	Cyan:   []byte("C:"),
+}
+
+var highlightTests = []struct {
+	in    string
+	level commandr.ExecLevel
+	out   string
+}{
+	{in: "group sub leaf x", out: "[G:group] [G:sub] [G:leaf] x"},
+	{in: "grp sub", out: "[R:grp] sub"},
+	{in: "group --verbose run", out: "[G:group] [Y:--verbose] [G:run]"},
+	{in: `group sub leaf "hello world" -x`, out: `[G:group] [G:sub] [G:leaf] [C:"hello world"] [Y:-x]`},
+	{in: "group sub admin", level: commandr.User, out: "[G:group] [G:sub] admin"},
+	{in: "group sub admin", level: commandr.Admin, out: "[G:group] [G:sub] [G:admin]"},
+	{in: "help group  sub", out: "[G:help] [G:group]  [G:sub]"},
+	{in: `group 'a b`, out: `[G:group] [C:'a b]`},
+}
+
+func TestHighlighter(t *testing.T) {
+	for _, test := range highlightTests {
+		level := test.level
+		if level == commandr.All {
+			level = commandr.User
+		}
+		client := &commandrtest.Client{Level: level}
+		highlight := threeLevelTree().Highlighter(client, markerEscapes)
+
+		var out strings.Builder
+		for _, segment := range highlight(test.in) {
+			if len(segment.Style) > 0 {
+				out.WriteString("[" + string(segment.Style) + segment.Text + "]")
+			} else {
+				out.WriteString(segment.Text)
+			}
+		}
+		if out.String() != test.out {
+			t.Errorf("%q highlighted as %q, expected %q", test.in, out.String(), test.out)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package commandr
+
+import (
+	"io"
+	"strings"
+)
+
+// Hinter returns a function to use as the Hinter of a term.Terminal. While a command name is typed it hints the rest
+// of the name when a single command available to client matches, and once a command is complete it hints the args
+// expected by its Use line.
+func (c *Command) Hinter(client io.Writer) func(line string) string {
+	return func(line string) string {
+		words := splitWords(line)
+		cmd := c
+
+		for i, w := range words {
+			if w.space || strings.HasPrefix(w.text, "-") {
+				continue
+			}
+			sub := cmd.findSubCommand(client, w.text)
+			if i < len(words)-1 {
+				if sub == nil {
+					// The args of cmd have been started.
+					return ""
+				}
+				cmd = sub
+				continue
+			}
+
+			if sub == nil {
+				return cmd.completeName(client, w.text)
+			}
+			if args := sub.useArgs(); args != "" {
+				return " " + args
+			}
+			return ""
+		}
+
+		if cmd == c {
+			return ""
+		}
+		return cmd.useArgs()
+	}
+}
+
+// completeName returns the rest of the name of the single sub command available to client that starts with prefix.
+func (c *Command) completeName(client io.Writer, prefix string) string {
+	var match *Command
+	for _, cmd := range c.commands {
+		if cmd.Hidden || !cmd.CanExecute(client) || !strings.HasPrefix(cmd.Name(), prefix) {
+			continue
+		}
+		if match != nil {
+			return ""
+		}
+		match = cmd
+	}
+	if match == nil {
+		return ""
+	}
+	return strings.TrimPrefix(match.Name(), prefix)
+}
+
+// useArgs returns the args part of the Use line.
+func (c *Command) useArgs() string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(c.Use), c.Name()))
+}
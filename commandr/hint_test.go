@@ -0,0 +1,40 @@
+package commandr_test
+
+import (
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+)
+
+var hintTests = []struct {
+	in    string
+	level commandr.ExecLevel
+	hint  string
+}{
+	{in: "gr", hint: "oup"},
+	{in: "x", hint: ""},
+	{in: "group s", hint: "ub"},
+	{in: "group r", hint: "un"},
+	{in: "group sub leaf", hint: " <name>"},
+	{in: "group sub leaf ", hint: "<name>"},
+	{in: "group sub leaf -v ", hint: "<name>"},
+	{in: "group sub leaf bob", hint: ""},
+	{in: "group sub leaf bob ", hint: ""},
+	{in: "group sub a", level: commandr.User, hint: ""},
+	{in: "group sub a", level: commandr.Admin, hint: "dmin"},
+}
+
+func TestHinter(t *testing.T) {
+	for _, test := range hintTests {
+		level := test.level
+		if level == commandr.All {
+			level = commandr.User
+		}
+		client := &commandrtest.Client{Level: level}
+		hint := threeLevelTree().Hinter(client)(test.in)
+		if hint != test.hint {
+			t.Errorf("hint for %q was %q, expected %q", test.in, hint, test.hint)
+		}
+	}
+}
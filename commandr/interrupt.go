@@ -0,0 +1,20 @@
+package commandr
+
+import "io"
+
+// InterruptibleClient is implemented by clients that let the user interrupt a running command, such as a local
+// console where Ctrl-C stops the command instead of the program.
+type InterruptibleClient interface {
+	Interrupted() <-chan struct{}
+}
+
+// Interrupted returns a channel that is closed when the user interrupts the running command. Long running commands
+// should select on it and return early. If the client does not implement InterruptibleClient a nil channel, which
+// is never ready, is returned.
+func Interrupted(client io.Writer) <-chan struct{} {
+	ic, ok := client.(InterruptibleClient)
+	if !ok {
+		return nil
+	}
+	return ic.Interrupted()
+}
@@ -0,0 +1,25 @@
+package commandr
+
+import (
+	"errors"
+	"io"
+)
+
+// Prompter is implemented by clients that are able to read a line of input from the user, such as a
+// term.Terminal session.
+type Prompter interface {
+	Prompt(prompt string) (string, error)
+}
+
+// ErrNoInput is returned by Prompt when the client is not able to read input.
+var ErrNoInput = errors.New("client does not support interactive input")
+
+// Prompt displays prompt on the client and returns the line entered by the user. If the client does not implement
+// Prompter ErrNoInput is returned.
+func Prompt(client io.Writer, prompt string) (string, error) {
+	p, ok := client.(Prompter)
+	if !ok {
+		return "", ErrNoInput
+	}
+	return p.Prompt(prompt)
+}
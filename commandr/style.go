@@ -0,0 +1,42 @@
+package commandr
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/alexj212/gox/term"
+)
+
+// StyledClient is implemented by clients that know which styles the terminal they are displayed on can show, such
+// as term.Terminal.
+type StyledClient interface {
+	Capabilities() term.Capabilities
+}
+
+// ClientCapabilities returns the styles the client's terminal can show. Clients that are not a StyledClient get 16
+// colors if they support ANSI escape sequences and no styling otherwise.
+func ClientCapabilities(client io.Writer) term.Capabilities {
+	if sc, ok := client.(StyledClient); ok {
+		return sc.Capabilities()
+	}
+	if ClientSupportsANSI(client) {
+		return term.Capabilities{Colors: term.Colors16}
+	}
+	return term.Capabilities{}
+}
+
+// Styled returns text in style for the client. Colors are degraded to the ones the client can show and text is
+// returned as it is when the client has no styling.
+func Styled(client io.Writer, style term.Style, text string) string {
+	return ClientCapabilities(client).Render(style, text)
+}
+
+// Styledf formats according to a format specifier and writes the result to the client in style.
+func Styledf(client io.Writer, style term.Style, format string, a ...interface{}) {
+	client.Write([]byte(Styled(client, style, fmt.Sprintf(format, a...))))
+}
+
+// Link returns text as a hyperlink to url for the client, or text on its own if the client can't show hyperlinks.
+func Link(client io.Writer, url, text string) string {
+	return ClientCapabilities(client).Hyperlink(url, text)
+}
@@ -0,0 +1,47 @@
+package commandr_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+	"github.com/alexj212/gox/term"
+)
+
+func TestStyled(t *testing.T) {
+	style := term.Style{Bold: true, Fg: term.RGB(255, 0, 0)}
+
+	plain := &commandrtest.Client{}
+	if got := commandr.Styled(plain, style, "error"); got != "error" {
+		t.Errorf("styled text for a client without ANSI was %q", got)
+	}
+	if got := commandr.Link(plain, "https://go.dev", "go"); got != "go" {
+		t.Errorf("link for a client without ANSI was %q", got)
+	}
+
+	ansi := &commandrtest.Client{ANSI: true}
+	if got, expected := commandr.Styled(ansi, style, "error"), "\x1b[1;91merror\x1b[0m"; got != expected {
+		t.Errorf("styled text was %q, expected %q", got, expected)
+	}
+
+	commandr.Styledf(ansi, term.Style{Underline: true}, "%d items", 3)
+	if got, expected := ansi.Stdout.String(), "\x1b[4m3 items\x1b[0m"; got != expected {
+		t.Errorf("Styledf wrote %q, expected %q", got, expected)
+	}
+}
+
+func TestClientSupportsUTF8(t *testing.T) {
+	ss := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(""), io.Discard}, "> ")
+	if !commandr.ClientSupportsUTF8(ss) {
+		t.Errorf("terminal does not support UTF-8 by default")
+	}
+	ss.SetUTF8(false)
+	if commandr.ClientSupportsUTF8(ss) {
+		t.Errorf("terminal supports UTF-8 after SetUTF8(false)")
+	}
+}
@@ -4,13 +4,68 @@ import (
 	"fmt"
 	markdownP "github.com/MichaelMure/go-term-markdown"
 	"io"
+	"os"
 	"strings"
 	"time"
+
+	"github.com/alexj212/gox/utilx"
 )
 
+// defaultWidth is the width used for clients that do not report their size.
+const defaultWidth = 80
+
+// SizedClient is implemented by clients that know the size of the terminal they are displayed on, such as
+// term.Terminal.
+type SizedClient interface {
+	Size() (width, height int)
+}
+
+// ANSIClient is implemented by clients that know if the terminal they are displayed on supports ANSI escape
+// sequences, such as term.Terminal.
+type ANSIClient interface {
+	SupportsANSI() bool
+}
+
+// ClientWidth returns the width of the client's terminal, or 80 if it is not known.
+func ClientWidth(client io.Writer) int {
+	if sc, ok := client.(SizedClient); ok {
+		if width, _ := sc.Size(); width > 0 {
+			return width
+		}
+	}
+	return defaultWidth
+}
+
+// ClientSupportsANSI determines if ANSI escape sequences can be written to the client.
+func ClientSupportsANSI(client io.Writer) bool {
+	ac, ok := client.(ANSIClient)
+	return ok && ac.SupportsANSI()
+}
+
+// UTF8Client is implemented by clients that know if the terminal they are displayed on shows UTF-8, such as a session
+// that received the locale of the user.
+type UTF8Client interface {
+	SupportsUTF8() bool
+}
+
+// ClientSupportsUTF8 determines if text beyond ASCII, such as box drawing characters, can be written to the client.
+// Clients that are not a UTF8Client are decided by the locale of the program, see utilx.LocaleIsUTF8.
+func ClientSupportsUTF8(client io.Writer) bool {
+	if uc, ok := client.(UTF8Client); ok {
+		return uc.SupportsUTF8()
+	}
+	return utilx.LocaleIsUTF8(os.Getenv)
+}
+
 // RenderMarkdown render the markdown string in terminal
 func RenderMarkdown(w io.Writer, markdown string) {
-	result := markdownP.Render(markdown, 80, 6)
+	RenderMarkdownWidth(w, markdown, ClientWidth(w), 6)
+}
+
+// RenderMarkdownWidth render the markdown string in terminal, wrapping lines at width and indenting them with
+// leftPad spaces.
+func RenderMarkdownWidth(w io.Writer, markdown string, width, leftPad int) {
+	result := markdownP.Render(markdown, width, leftPad)
 	w.Write(result)
 }
 
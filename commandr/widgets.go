@@ -0,0 +1,83 @@
+package commandr
+
+import (
+	"io"
+
+	"github.com/alexj212/gox/term"
+)
+
+// Selector is implemented by clients that let the user pick from a list of options, such as a term.Terminal session.
+type Selector interface {
+	Select(prompt string, options []string) (int, error)
+	MultiSelect(prompt string, options []string) ([]int, error)
+}
+
+// Confirmer is implemented by clients that ask the user yes or no questions, such as a term.Terminal session.
+type Confirmer interface {
+	Confirm(prompt string, defaultYes bool) (bool, error)
+}
+
+// Inputter is implemented by clients that read validated text from the user, such as a term.Terminal session.
+type Inputter interface {
+	Input(prompt, defaultValue string, validate func(string) error) (string, error)
+}
+
+// Spinner is implemented by clients that show the progress of a running command, such as a term.Terminal session.
+type Spinner interface {
+	StartSpinner(label string) *term.Progress
+}
+
+// Select asks the user to pick one of options and returns its index. If the client does not implement Selector
+// ErrNoInput is returned.
+func Select(client io.Writer, prompt string, options []string) (int, error) {
+	s, ok := client.(Selector)
+	if !ok {
+		return -1, ErrNoInput
+	}
+	return s.Select(prompt, options)
+}
+
+// MultiSelect asks the user to pick any number of options and returns their indexes. If the client does not
+// implement Selector ErrNoInput is returned.
+func MultiSelect(client io.Writer, prompt string, options []string) ([]int, error) {
+	s, ok := client.(Selector)
+	if !ok {
+		return nil, ErrNoInput
+	}
+	return s.MultiSelect(prompt, options)
+}
+
+// Confirm asks the user a yes or no question. If the client does not implement Confirmer ErrNoInput is returned.
+func Confirm(client io.Writer, prompt string, defaultYes bool) (bool, error) {
+	c, ok := client.(Confirmer)
+	if !ok {
+		return false, ErrNoInput
+	}
+	return c.Confirm(prompt, defaultYes)
+}
+
+// Input asks the user for text, returning defaultValue for an empty answer and asking again until validate, if not
+// nil, accepts the answer. If the client does not implement Inputter ErrNoInput is returned.
+func Input(client io.Writer, prompt, defaultValue string, validate func(string) error) (string, error) {
+	i, ok := client.(Inputter)
+	if !ok {
+		return "", ErrNoInput
+	}
+	return i.Input(prompt, defaultValue, validate)
+}
+
+// StartSpinner shows an animated spinner with label on the client while a command works and returns the function
+// that removes it, writing message in its place if it is not empty. Clients that do not implement Spinner get label
+// written when the spinner starts.
+func StartSpinner(client io.Writer, label string) (done func(message string)) {
+	s, ok := client.(Spinner)
+	if !ok {
+		client.Write([]byte(label + "\n"))
+		return func(message string) {
+			if message != "" {
+				client.Write([]byte(message + "\n"))
+			}
+		}
+	}
+	return s.StartSpinner(label).Done
+}
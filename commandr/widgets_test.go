@@ -0,0 +1,58 @@
+package commandr_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/commandr/commandrtest"
+	"github.com/alexj212/gox/term"
+)
+
+type scripted struct {
+	*strings.Reader
+	out bytes.Buffer
+}
+
+func (s *scripted) Write(p []byte) (int, error) {
+	return s.out.Write(p)
+}
+
+func TestWidgets(t *testing.T) {
+	client := term.NewTerminal(&scripted{Reader: strings.NewReader("\x1b[B\ry\rbob\r")}, "> ")
+	if i, err := commandr.Select(client, "pick", []string{"a", "b"}); i != 1 || err != nil {
+		t.Errorf("Select returned %d, %v", i, err)
+	}
+	if ok, err := commandr.Confirm(client, "sure?", false); !ok || err != nil {
+		t.Errorf("Confirm returned %t, %v", ok, err)
+	}
+	if s, err := commandr.Input(client, "name?", "alice", nil); s != "alice" || err != nil {
+		t.Errorf("Input returned %q, %v", s, err)
+	}
+	if s, err := commandr.Input(client, "name?", "alice", nil); s != "bob" || err != nil {
+		t.Errorf("Input returned %q, %v", s, err)
+	}
+}
+
+func TestWidgetsWithoutInput(t *testing.T) {
+	client := &commandrtest.Client{}
+	if _, err := commandr.Select(client, "pick", []string{"a"}); err != commandr.ErrNoInput {
+		t.Errorf("Select returned %v", err)
+	}
+	if _, err := commandr.MultiSelect(client, "pick", []string{"a"}); err != commandr.ErrNoInput {
+		t.Errorf("MultiSelect returned %v", err)
+	}
+	if _, err := commandr.Confirm(client, "sure?", true); err != commandr.ErrNoInput {
+		t.Errorf("Confirm returned %v", err)
+	}
+	if _, err := commandr.Input(client, "name?", "", nil); err != commandr.ErrNoInput {
+		t.Errorf("Input returned %v", err)
+	}
+
+	done := commandr.StartSpinner(client, "loading")
+	done("loaded")
+	if got, expected := client.Stdout.String(), "loading\nloaded\n"; got != expected {
+		t.Errorf("spinner wrote %q, expected %q", got, expected)
+	}
+}
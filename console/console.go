@@ -0,0 +1,203 @@
+// Package console runs a commandr command tree as an interactive REPL on the local terminal.
+//
+// A Console puts stdin into raw mode, edits lines with a term.Terminal, keeps the terminal size up to date and
+// dispatches every entered line to the command tree:
+//
+//	c := console.New(commands, "> ")
+//	if err := c.Run(); err != nil {
+//	        log.Fatal(err)
+//	}
+//
+// Ctrl-C discards the line being edited, or interrupts the running command when one is executing. Commands see the
+// interrupt on commandr.Interrupted(client). F1 shows the help of the command being entered. Ctrl-D on an empty line
+// and the exit command end Run. The terminal is restored when Run returns, even after a panic.
+package console
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/term"
+	"github.com/alexj212/gox/utilx"
+)
+
+// ErrNotTerminal is returned by Run when stdin is not a terminal.
+var ErrNotTerminal = errors.New("console: stdin is not a terminal")
+
+// Console is a REPL for a command tree on the local terminal. It embeds the term.Terminal used to edit lines, so it
+// is the client passed to commands and supports prompting, paging and the other terminal capabilities.
+type Console struct {
+	*term.Terminal
+
+	// Handler executes an entered line. It defaults to commandr.HandleCommands for the commands passed to New.
+	Handler func(client io.Writer, line string)
+
+	commands *commandr.Command
+	// fd is the file descriptor of the raw terminal, or -1 if the console does not run on one.
+	fd int
+
+	lock sync.Mutex
+	// interrupt is closed when the running command is interrupted. It is nil while no command is running.
+	interrupt chan struct{}
+}
+
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+// New returns a console that runs commands on stdin and stdout with the given prompt. The styles of the terminal and
+// whether it shows UTF-8 are detected from the environment and key bindings are loaded from the user's inputrc file,
+// see term.KeymapFile.
+func New(commands *commandr.Command, prompt string) *Console {
+	c := newConsole(stdio{os.Stdin, os.Stdout}, commands, prompt)
+	c.SetCapabilities(term.EnvCapabilities())
+	c.SetUTF8(utilx.LocaleIsUTF8(os.Getenv))
+	if path := term.KeymapFile(); path != "" {
+		c.loadKeymap(path, os.Stderr)
+	}
+	return c
+}
+
+// loadKeymap loads the key bindings of the inputrc file at path. Errors are written to errOut; the bindings that
+// could be read are used anyway. A missing file is not an error.
+func (c *Console) loadKeymap(path string, errOut io.Writer) {
+	keymap := term.NewKeymap()
+	err := keymap.LoadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(errOut, "console: %s: %v\n", path, err)
+	}
+	c.SetKeymap(keymap)
+}
+
+func newConsole(rw io.ReadWriter, commands *commandr.Command, prompt string) *Console {
+	c := &Console{
+		Handler:  commandr.HandleCommands(commands),
+		commands: commands,
+		fd:       -1,
+	}
+	c.Terminal = term.NewTerminal(rw, prompt)
+	c.Terminal.SetLineInterrupt(true)
+	c.Terminal.CompleteCallback = commands.Completer(c)
+	c.Terminal.ContinuationCallback = commandr.NeedsContinuation
+	c.Terminal.BindKey(term.KeyEvent{Key: term.KeyF1}, c.help)
+	return c
+}
+
+// help shows the help of the command being entered when F1 is pressed.
+func (c *Console) help(line string, pos int) (string, int) {
+	c.Handler(c, "help "+line)
+	return line, pos
+}
+
+// Run reads and executes lines until Ctrl-D is pressed on an empty line, the exit command is run or stdin is
+// closed. Stdin is put into raw mode for the duration of Run.
+func (c *Console) Run() (err error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	c.fd = fd
+	defer func() { c.fd = -1 }()
+
+	if width, height, err := term.GetSize(fd); err == nil {
+		c.SetSize(width, height)
+	}
+	stop := watchSize(fd, c.Terminal)
+	defer stop()
+
+	return c.serve()
+}
+
+// serve runs the read, execute loop on the terminal.
+func (c *Console) serve() error {
+	for {
+		line, err := c.ReadLine()
+		if err == term.ErrInterrupted {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		c.execute(line)
+		if c.isExit(line) {
+			return nil
+		}
+	}
+}
+
+// execute runs line with Ctrl-C routed to the command as an interrupt.
+func (c *Console) execute(line string) {
+	c.lock.Lock()
+	c.interrupt = make(chan struct{})
+	c.lock.Unlock()
+
+	if c.fd >= 0 {
+		stop := notifyInterrupt(c.fd, c.interruptCommand)
+		defer stop()
+	}
+	defer func() {
+		c.lock.Lock()
+		c.interrupt = nil
+		c.lock.Unlock()
+	}()
+
+	c.Handler(c, line)
+}
+
+// interruptCommand interrupts the running command.
+func (c *Console) interruptCommand() {
+	c.lock.Lock()
+	interrupted := false
+	if c.interrupt != nil {
+		select {
+		case <-c.interrupt:
+		default:
+			close(c.interrupt)
+			interrupted = true
+		}
+	}
+	c.lock.Unlock()
+
+	if interrupted {
+		c.Terminal.Write([]byte("^C\n"))
+	}
+}
+
+// Interrupted returns a channel that is closed when the user presses Ctrl-C while the current command runs. It
+// implements commandr.InterruptibleClient.
+func (c *Console) Interrupted() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.interrupt
+}
+
+// isExit returns true if line runs commandr.ExitCommand.
+func (c *Console) isExit(line string) bool {
+	parsed, err := commandr.NewCommandArgs(line, io.Discard)
+	if err != nil {
+		return false
+	}
+	cmd, _ := c.commands.Find(c, parsed)
+	return cmd == commandr.ExitCommand
+}
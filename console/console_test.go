@@ -0,0 +1,127 @@
+package console
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/term"
+)
+
+// scriptedIO feeds input to the console and records its output.
+type scriptedIO struct {
+	in  *strings.Reader
+	out bytes.Buffer
+}
+
+func (s *scriptedIO) Read(buf []byte) (int, error)  { return s.in.Read(buf) }
+func (s *scriptedIO) Write(buf []byte) (int, error) { return s.out.Write(buf) }
+
+func newTestConsole(input string) (*Console, *scriptedIO, *[]string) {
+	rw := &scriptedIO{in: strings.NewReader(input)}
+	c := newConsole(rw, commandr.DefaultCommands, "> ")
+	var lines []string
+	handler := c.Handler
+	c.Handler = func(client io.Writer, line string) {
+		lines = append(lines, line)
+		handler(client, line)
+	}
+	return c, rw, &lines
+}
+
+func TestServeExit(t *testing.T) {
+	c, _, lines := newTestConsole("pager off\rexit\rpager on\r")
+	if err := c.serve(); err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if got, expected := strings.Join(*lines, "|"), "pager off|exit"; got != expected {
+		t.Errorf("executed %q, expected %q", got, expected)
+	}
+}
+
+func TestServeCtrlD(t *testing.T) {
+	c, _, lines := newTestConsole("abc\x03pager off\r\x04pager on\r")
+	if err := c.serve(); err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if got, expected := strings.Join(*lines, "|"), "pager off"; got != expected {
+		t.Errorf("executed %q, expected %q", got, expected)
+	}
+}
+
+func TestInterruptLine(t *testing.T) {
+	c, rw, _ := newTestConsole("abc\x03\x04")
+	if err := c.serve(); err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if got, expected := rw.out.String(), "> abc^C\r\n> "; got != expected {
+		t.Errorf("output was %q, expected %q", got, expected)
+	}
+}
+
+func TestInterruptCommand(t *testing.T) {
+	c, rw, _ := newTestConsole("wait\r\x04")
+	if commandr.Interrupted(c) != nil {
+		t.Errorf("Interrupted returned a channel while no command runs")
+	}
+
+	interrupted := false
+	c.Handler = func(client io.Writer, line string) {
+		c.interruptCommand()
+		select {
+		case <-commandr.Interrupted(client):
+			interrupted = true
+		default:
+		}
+		// A second interrupt of the same command is ignored.
+		c.interruptCommand()
+	}
+	if err := c.serve(); err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if !interrupted {
+		t.Errorf("command was not interrupted")
+	}
+	if got := strings.Count(rw.out.String(), "^C"); got != 1 {
+		t.Errorf("^C was written %d times, expected once", got)
+	}
+}
+
+func TestHelpKey(t *testing.T) {
+	c, _, lines := newTestConsole("pager\x1bOP\r\x04")
+	if err := c.serve(); err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if got, expected := strings.Join(*lines, "|"), "help pager|pager"; got != expected {
+		t.Errorf("executed %q, expected %q", got, expected)
+	}
+}
+
+func TestLoadKeymap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputrc")
+	inputrc := "\"\\C-a end-of-line\n\"\\e\\e[C\": forward-word\n\"\\C-e\": beginning-of-line\n"
+	if err := os.WriteFile(path, []byte(inputrc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _, _ := newTestConsole("")
+	var errOut bytes.Buffer
+	c.loadKeymap(path, &errOut)
+	if !strings.Contains(errOut.String(), "line 1") {
+		t.Errorf("load error was not reported: %q", errOut.String())
+	}
+	if c.Keymap() == nil || c.Keymap().Action(term.KeyEvent{Key: 'e', Mod: term.ModCtrl}) != "beginning-of-line" {
+		t.Errorf("bindings after the bad line were not loaded")
+	}
+
+	c, _, _ = newTestConsole("")
+	errOut.Reset()
+	c.loadKeymap(filepath.Join(t.TempDir(), "missing"), &errOut)
+	if errOut.Len() != 0 || c.Keymap() != nil {
+		t.Errorf("missing inputrc was reported: %q", errOut.String())
+	}
+}
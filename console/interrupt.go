@@ -0,0 +1,35 @@
+package console
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/alexj212/gox/term"
+)
+
+// notifyInterrupt lets Ctrl-C on the raw terminal fd raise an interrupt signal and calls interrupt for every
+// signal received until the returned stop function is called. Ctrl-\ and Ctrl-Z stay disabled, so they can't kill
+// or stop the process while the terminal is raw.
+func notifyInterrupt(fd int, interrupt func()) (stop func()) {
+	term.SetSignals(fd, true)
+
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				interrupt()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+		term.SetSignals(fd, false)
+	}
+}
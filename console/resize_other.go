@@ -0,0 +1,39 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !zos
+
+package console
+
+import (
+	"time"
+
+	"github.com/alexj212/gox/term"
+)
+
+// sizePollInterval is how often the terminal size is checked on platforms without SIGWINCH.
+const sizePollInterval = 250 * time.Millisecond
+
+// watchSize polls the terminal size and updates t when it changes until the returned stop function is called.
+func watchSize(fd int, t *term.Terminal) (stop func()) {
+	width, height, _ := term.GetSize(fd)
+	ticker := time.NewTicker(sizePollInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err != nil || (w == width && h == height) {
+					continue
+				}
+				width, height = w, h
+				t.SetSize(width, height)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+package console
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alexj212/gox/term"
+)
+
+// watchSize updates the size of t on every SIGWINCH until the returned stop function is called.
+func watchSize(fd int, t *term.Terminal) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGWINCH)
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				if width, height, err := term.GetSize(fd); err == nil {
+					t.SetSize(width, height)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
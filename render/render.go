@@ -0,0 +1,122 @@
+// Package render lays out tables and trees as text for a terminal.
+//
+// Output is sized to the width of the terminal it is written to and drawn with box drawing characters, or with
+// plain ASCII on terminals without UTF-8:
+//
+//	t := render.NewTable("NAME", "STATUS")
+//	t.AddRow("web", "running")
+//	t.Print(client)
+//
+// Cells and labels may contain styles, such as the ones returned by commandr.Styled, which take no space.
+package render
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexj212/gox/commandr"
+	"github.com/alexj212/gox/term"
+	"github.com/alexj212/gox/utilx"
+)
+
+// Options control how tables and trees are laid out.
+type Options struct {
+	// Width is the number of columns available. Zero means that lines are never shortened.
+	Width int
+	// ASCII draws borders, tree branches and ellipses with ASCII characters only.
+	ASCII bool
+}
+
+// ForClient returns the options for output written to a commandr client, sized to the client's terminal.
+func ForClient(client io.Writer) Options {
+	return Options{
+		Width: commandr.ClientWidth(client),
+		ASCII: !commandr.ClientSupportsUTF8(client),
+	}
+}
+
+// ForTerminal returns the options for output written to the local terminal fd, sized with term.GetSize. If fd is not
+// a terminal the width is not limited.
+func ForTerminal(fd int) Options {
+	opts := Options{ASCII: !utilx.LocaleIsUTF8(os.Getenv)}
+	if width, _, err := term.GetSize(fd); err == nil {
+		opts.Width = width
+	}
+	return opts
+}
+
+// ellipsis returns the text marking shortened text.
+func (o Options) ellipsis() string {
+	if o.ASCII {
+		return "..."
+	}
+	return "…"
+}
+
+// truncate shortens line to width columns, ending it with an ellipsis if it was shortened.
+func (o Options) truncate(line string, width int) string {
+	if term.StringWidth(line) <= width {
+		return line
+	}
+	ellipsis := o.ellipsis()
+	ellipsisWidth := term.StringWidth(ellipsis)
+	if width < ellipsisWidth {
+		ellipsis, ellipsisWidth = "", 0
+	}
+	prefix, _ := term.FitString(line, width-ellipsisWidth)
+	if strings.Contains(prefix, "\x1b") {
+		// The style the text ends with may have been cut off.
+		prefix += term.StyleReset
+	}
+	return prefix + ellipsis
+}
+
+// wrap breaks line into lines of at most width columns, between words where possible.
+func wrap(line string, width int) []string {
+	if width < 1 || term.StringWidth(line) <= width {
+		return []string{line}
+	}
+
+	var lines []string
+	var current string
+	currentWidth := 0
+	for _, word := range strings.Fields(line) {
+		wordWidth := term.StringWidth(word)
+		if currentWidth > 0 && currentWidth+1+wordWidth <= width {
+			current += " " + word
+			currentWidth += 1 + wordWidth
+			continue
+		}
+		if currentWidth > 0 {
+			lines = append(lines, current)
+		}
+		for wordWidth > width {
+			prefix, prefixWidth := term.FitString(word, width)
+			if prefixWidth == 0 {
+				// A glyph wider than the column.
+				prefix, prefixWidth = string([]rune(word)[:1]), width
+			}
+			lines = append(lines, prefix)
+			word = word[len(prefix):]
+			wordWidth -= prefixWidth
+		}
+		current, currentWidth = word, wordWidth
+	}
+	return append(lines, current)
+}
+
+// pad fills text with spaces to width columns.
+func pad(text string, width int, align Align) string {
+	space := width - term.StringWidth(text)
+	if space <= 0 {
+		return text
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", space) + text
+	case AlignCenter:
+		return strings.Repeat(" ", space/2) + text + strings.Repeat(" ", space-space/2)
+	}
+	return text + strings.Repeat(" ", space)
+}
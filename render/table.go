@@ -0,0 +1,244 @@
+package render
+
+import (
+	"io"
+	"strings"
+
+	"github.com/alexj212/gox/term"
+)
+
+// Align is the alignment of the cells of a column.
+type Align int
+
+// Alignments of a column.
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+// Column describes a column of a table.
+type Column struct {
+	Header string
+	Align  Align
+	// MaxWidth limits the width of the column. Zero means no limit other than the width of the terminal.
+	MaxWidth int
+	// Wrap breaks cells that are too wide onto more lines. Otherwise they are shortened with an ellipsis.
+	Wrap bool
+}
+
+// Table is a table of text. Cells may span several lines.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+	// Border draws a frame around the table and lines between the cells. Without border, columns are separated by
+	// two spaces and the header is underlined.
+	Border bool
+}
+
+// NewTable returns a table with a column for each header.
+func NewTable(headers ...string) *Table {
+	t := &Table{}
+	for _, header := range headers {
+		t.Columns = append(t.Columns, Column{Header: header})
+	}
+	return t
+}
+
+// AddRow adds a row of cells to the table.
+func (t *Table) AddRow(cells ...string) *Table {
+	t.Rows = append(t.Rows, cells)
+	return t
+}
+
+// Print writes the table to a commandr client, sized to the client's terminal.
+func (t *Table) Print(client io.Writer) {
+	client.Write([]byte(t.Render(ForClient(client))))
+}
+
+// tableChars are the characters a table is drawn with. The corners and crossings are given for the top, middle and
+// bottom lines, from left to right.
+type tableChars struct {
+	horizontal, vertical string
+	top, middle, bottom  [3]string
+}
+
+var (
+	unicodeTable = tableChars{"─", "│", [3]string{"┌", "┬", "┐"}, [3]string{"├", "┼", "┤"}, [3]string{"└", "┴", "┘"}}
+	asciiTable   = tableChars{"-", "|", [3]string{"+", "+", "+"}, [3]string{"+", "+", "+"}, [3]string{"+", "+", "+"}}
+)
+
+// Render returns the table laid out with opts. Every line ends with a newline.
+func (t *Table) Render(opts Options) string {
+	columns := t.columns()
+	if len(columns) == 0 {
+		return ""
+	}
+	widths := t.layout(columns, opts.Width)
+
+	chars := unicodeTable
+	if opts.ASCII {
+		chars = asciiTable
+	}
+
+	var b strings.Builder
+	rule := func(corners [3]string, fill string) {
+		b.WriteString(corners[0])
+		for i, width := range widths {
+			if i > 0 {
+				b.WriteString(corners[1])
+			}
+			b.WriteString(strings.Repeat(fill, width+2))
+		}
+		b.WriteString(corners[2] + "\n")
+	}
+	row := func(cells []string) {
+		lines := make([][]string, len(columns))
+		height := 1
+		for i, column := range columns {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			lines[i] = cellLines(cell, widths[i], column.Wrap, opts)
+			height = max(height, len(lines[i]))
+		}
+		for y := 0; y < height; y++ {
+			var line strings.Builder
+			if t.Border {
+				line.WriteString(chars.vertical + " ")
+			}
+			for i, column := range columns {
+				if i > 0 {
+					if t.Border {
+						line.WriteString(" " + chars.vertical + " ")
+					} else {
+						line.WriteString("  ")
+					}
+				}
+				text := ""
+				if y < len(lines[i]) {
+					text = lines[i][y]
+				}
+				line.WriteString(pad(text, widths[i], column.Align))
+			}
+			if t.Border {
+				line.WriteString(" " + chars.vertical)
+				b.WriteString(line.String())
+			} else {
+				b.WriteString(strings.TrimRight(line.String(), " "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	headers := make([]string, len(columns))
+	hasHeader := false
+	for i, column := range columns {
+		headers[i] = column.Header
+		hasHeader = hasHeader || column.Header != ""
+	}
+
+	if t.Border {
+		rule(chars.top, chars.horizontal)
+	}
+	if hasHeader {
+		row(headers)
+		if t.Border {
+			rule(chars.middle, chars.horizontal)
+		} else {
+			underlines := make([]string, len(widths))
+			for i, width := range widths {
+				underlines[i] = strings.Repeat(chars.horizontal, width)
+			}
+			b.WriteString(strings.Join(underlines, "  ") + "\n")
+		}
+	}
+	for _, cells := range t.Rows {
+		row(cells)
+	}
+	if t.Border {
+		rule(chars.bottom, chars.horizontal)
+	}
+	return b.String()
+}
+
+// columns returns the columns of the table, adding columns without header for rows with more cells than columns.
+func (t *Table) columns() []Column {
+	columns := t.Columns
+	for _, cells := range t.Rows {
+		for len(columns) < len(cells) {
+			columns = append(columns[:len(columns):len(columns)], Column{})
+		}
+	}
+	return columns
+}
+
+// minColumnWidth is the width columns are not shrunk below to fit the table on the terminal.
+const minColumnWidth = 4
+
+// layout returns the width of each column. The widest columns are shrunk until the table fits in width columns.
+func (t *Table) layout(columns []Column, width int) []int {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = maxLineWidth(column.Header)
+		for _, cells := range t.Rows {
+			if i < len(cells) {
+				widths[i] = max(widths[i], maxLineWidth(cells[i]))
+			}
+		}
+		if column.MaxWidth > 0 {
+			widths[i] = min(widths[i], column.MaxWidth)
+		}
+	}
+	if width <= 0 {
+		return widths
+	}
+
+	// Lines are kept shorter than the terminal, so they don't wrap on
+	// terminals that move to the next line after the last column.
+	available := width - 1 - 2*(len(columns)-1)
+	if t.Border {
+		available -= 4 + len(columns) - 1
+	}
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	for total > available {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
+
+// maxLineWidth returns the width of the widest line of text.
+func maxLineWidth(text string) int {
+	width := 0
+	for _, line := range strings.Split(text, "\n") {
+		width = max(width, term.StringWidth(line))
+	}
+	return width
+}
+
+// cellLines returns the lines a cell is shown on in a column of the given width.
+func cellLines(cell string, width int, wrapCell bool, opts Options) []string {
+	var lines []string
+	for _, line := range strings.Split(cell, "\n") {
+		if wrapCell {
+			lines = append(lines, wrap(line, width)...)
+		} else {
+			lines = append(lines, opts.truncate(line, width))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	table := NewTable("NAME", "SIZE")
+	table.Columns[1].Align = AlignRight
+	table.AddRow("alpha", "1")
+	table.AddRow("be", "200")
+
+	tests := []struct {
+		border   bool
+		opts     Options
+		expected string
+	}{
+		{false, Options{}, "NAME   SIZE\n" + "─────  ────\n" + "alpha     1\n" + "be      200\n"},
+		{false, Options{ASCII: true}, "NAME   SIZE\n" + "-----  ----\n" + "alpha     1\n" + "be      200\n"},
+		{true, Options{}, "┌───────┬──────┐\n" +
+			"│ NAME  │ SIZE │\n" +
+			"├───────┼──────┤\n" +
+			"│ alpha │    1 │\n" +
+			"│ be    │  200 │\n" +
+			"└───────┴──────┘\n"},
+		{true, Options{ASCII: true}, "+-------+------+\n" +
+			"| NAME  | SIZE |\n" +
+			"+-------+------+\n" +
+			"| alpha |    1 |\n" +
+			"| be    |  200 |\n" +
+			"+-------+------+\n"},
+	}
+	for i, test := range tests {
+		table.Border = test.border
+		if got := table.Render(test.opts); got != test.expected {
+			t.Errorf("#%d: got\n%s\nexpected\n%s", i, got, test.expected)
+		}
+	}
+}
+
+func TestTableWidth(t *testing.T) {
+	table := &Table{Columns: []Column{{Header: "ID"}, {Header: "DESCRIPTION"}}}
+	table.AddRow("1", "a rather long description")
+
+	expected := "ID  DESCRIPTION\n" +
+		"──  ─────────────\n" +
+		"1   a rather lon…\n"
+	if got := table.Render(Options{Width: 18}); got != expected {
+		t.Errorf("truncated: got\n%s\nexpected\n%s", got, expected)
+	}
+
+	table.Columns[1].Wrap = true
+	expected = "ID  DESCRIPTION\n" +
+		"──  ─────────────\n" +
+		"1   a rather long\n" +
+		"    description\n"
+	if got := table.Render(Options{Width: 18}); got != expected {
+		t.Errorf("wrapped: got\n%s\nexpected\n%s", got, expected)
+	}
+
+	table.Columns[1].Wrap = false
+	table.Columns[1].MaxWidth = 8
+	expected = "ID  DESCR...\n" +
+		"--  --------\n" +
+		"1   a rat...\n"
+	if got := table.Render(Options{ASCII: true}); got != expected {
+		t.Errorf("max width: got\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestTableCells(t *testing.T) {
+	table := &Table{}
+	table.AddRow("\x1b[31mred\x1b[0m", "日本")
+	table.AddRow("two\nlines", "x", "extra")
+
+	expected := "\x1b[31mred\x1b[0m    日本\n" +
+		"two    x     extra\n" +
+		"lines\n"
+	if got := table.Render(Options{}); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		in       string
+		width    int
+		expected []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"one two three", 7, []string{"one two", "three"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"a 日本語", 4, []string{"a", "日本", "語"}},
+	}
+	for i, test := range tests {
+		got := wrap(test.in, test.width)
+		if len(got) != len(test.expected) {
+			t.Errorf("#%d: got %q, expected %q", i, got, test.expected)
+			continue
+		}
+		for j := range got {
+			if got[j] != test.expected[j] {
+				t.Errorf("#%d: got %q, expected %q", i, got, test.expected)
+				break
+			}
+		}
+	}
+}
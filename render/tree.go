@@ -0,0 +1,82 @@
+package render
+
+import (
+	"io"
+	"strings"
+)
+
+// Tree is a node of a hierarchy shown as a tree.
+type Tree struct {
+	// Label is the text shown for the node. It may span several lines.
+	Label    string
+	Children []*Tree
+}
+
+// NewTree returns a tree with a root node labeled label.
+func NewTree(label string) *Tree {
+	return &Tree{Label: label}
+}
+
+// Add adds a child labeled label to the node and returns the child.
+func (t *Tree) Add(label string) *Tree {
+	child := &Tree{Label: label}
+	t.Children = append(t.Children, child)
+	return child
+}
+
+// Print writes the tree to a commandr client, sized to the client's terminal.
+func (t *Tree) Print(client io.Writer) {
+	client.Write([]byte(t.Render(ForClient(client))))
+}
+
+// treeChars are the characters a tree is drawn with: the branch to a child, the branch to the last child, the line
+// passing children on the way to later ones and the indentation below the last child.
+type treeChars struct {
+	branch, last, pipe, space string
+}
+
+var (
+	unicodeTree = treeChars{"├── ", "└── ", "│   ", "    "}
+	asciiTree   = treeChars{"|-- ", "`-- ", "|   ", "    "}
+)
+
+// Render returns the tree laid out with opts. Every line ends with a newline. Lines that are too wide are shortened
+// with an ellipsis.
+func (t *Tree) Render(opts Options) string {
+	chars := unicodeTree
+	if opts.ASCII {
+		chars = asciiTree
+	}
+
+	var b strings.Builder
+	var walk func(node *Tree, first, rest string)
+	walk = func(node *Tree, first, rest string) {
+		// Further lines of the label line up with the label. If the node
+		// has children, they are indented like them, to the right of the
+		// line down to the children.
+		below := rest
+		if len(node.Children) > 0 {
+			below = rest + chars.pipe
+		}
+		for i, line := range strings.Split(node.Label, "\n") {
+			prefix := first
+			if i > 0 {
+				prefix = below
+			}
+			line = prefix + line
+			if opts.Width > 0 {
+				line = opts.truncate(line, opts.Width-1)
+			}
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+		for i, child := range node.Children {
+			if i == len(node.Children)-1 {
+				walk(child, rest+chars.last, rest+chars.space)
+			} else {
+				walk(child, rest+chars.branch, rest+chars.pipe)
+			}
+		}
+	}
+	walk(t, "", "")
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package render
+
+import "testing"
+
+func TestTree(t *testing.T) {
+	root := NewTree("/")
+	etc := root.Add("etc\nconfig")
+	etc.Add("hosts")
+	etc.Add("ssh").Add("sshd_config")
+	root.Add("home\n(users)")
+
+	expected := "/\n" +
+		"├── etc\n" +
+		"│   │   config\n" +
+		"│   ├── hosts\n" +
+		"│   └── ssh\n" +
+		"│       └── sshd_config\n" +
+		"└── home\n" +
+		"    (users)\n"
+	if got := root.Render(Options{}); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+
+	expected = "/\n" +
+		"|-- etc\n" +
+		"|   |   config\n" +
+		"|   |-- hosts\n" +
+		"|   `-- ssh\n" +
+		"|       `-- ssh...\n" +
+		"`-- home\n" +
+		"    (users)\n"
+	if got := root.Render(Options{Width: 19, ASCII: true}); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+}
@@ -0,0 +1,242 @@
+package term
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Completion is a candidate returned by a Terminal's CompleteCallback.
+type Completion struct {
+	// Text replaces the text being completed.
+	Text string
+	// Description, if not empty, is shown next to Text in the menu.
+	Description string
+}
+
+// completionMenu contains the state of the completion menu shown below the
+// input line.
+type completionMenu struct {
+	candidates []Completion
+	// selected is the index of the selected candidate, or -1 if none is
+	// selected yet.
+	selected int
+	// start is the position in the line of the text being completed and
+	// current is the text there now.
+	start   int
+	current []rune
+	// word is the text that was completed, restored when the menu is
+	// cancelled.
+	word []rune
+}
+
+// complete calls the CompleteCallback for the text before the cursor. A
+// single candidate, or the prefix shared by all candidates, is inserted
+// directly. Otherwise the candidates are shown in a menu.
+func (t *Terminal) complete() {
+	prefix := string(t.line[:t.pos])
+	suffix := string(t.line[t.pos:])
+
+	t.lock.Unlock()
+	start, candidates := t.CompleteCallback(prefix+suffix, len(prefix))
+	t.lock.Lock()
+
+	if len(candidates) == 0 || start < 0 || start > len(prefix) {
+		return
+	}
+	startPos := utf8.RuneCountInString(prefix[:start])
+	word := t.line[startPos:t.pos]
+
+	if len(candidates) == 1 {
+		t.replaceCompletion(startPos, len(word), []rune(candidates[0].Text))
+		return
+	}
+	if common := commonPrefix(candidates); len(common) > len(string(word)) && strings.HasPrefix(common, string(word)) {
+		t.replaceCompletion(startPos, len(word), []rune(common))
+		return
+	}
+
+	t.clearHint()
+	t.menu = &completionMenu{
+		candidates: candidates,
+		selected:   -1,
+		start:      startPos,
+		current:    append([]rune(nil), word...),
+		word:       append([]rune(nil), word...),
+	}
+	t.drawMenu()
+}
+
+// commonPrefix returns the longest prefix shared by the text of candidates.
+func commonPrefix(candidates []Completion) string {
+	common := candidates[0].Text
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c.Text, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	return common
+}
+
+// replaceCompletion replaces the n characters at start with text and moves
+// the cursor after it.
+func (t *Terminal) replaceCompletion(start, n int, text []rune) {
+	newLine := make([]rune, 0, len(t.line)-n+len(text))
+	newLine = append(newLine, t.line[:start]...)
+	newLine = append(newLine, text...)
+	newLine = append(newLine, t.line[start+n:]...)
+	t.setLine(newLine, start+len(text))
+}
+
+// selectCompletion selects the candidate next (or previous, if forward is
+// false) to the selected one and puts it into the line.
+func (t *Terminal) selectCompletion(forward bool) {
+	m := t.menu
+	n := len(m.candidates)
+	switch {
+	case forward:
+		m.selected = (m.selected + 1) % n
+	case m.selected <= 0:
+		m.selected = n - 1
+	default:
+		m.selected--
+	}
+
+	text := []rune(m.candidates[m.selected].Text)
+	t.replaceCompletion(m.start, len(m.current), text)
+	m.current = text
+	t.drawMenu()
+}
+
+// closeMenu removes the completion menu from the screen. If cancel is true
+// the text that was completed is restored.
+func (t *Terminal) closeMenu(cancel bool) {
+	m := t.menu
+	t.menu = nil
+	t.eraseBelowInput()
+	if cancel {
+		t.replaceCompletion(m.start, len(m.current), m.word)
+	}
+}
+
+// resetMenu forgets the completion menu without redrawing. It is used when
+// readLine returns early.
+func (t *Terminal) resetMenu() {
+	t.menu = nil
+}
+
+// eraseBelowInput erases the screen after the input line.
+func (t *Terminal) eraseBelowInput() {
+	t.moveCursorToPos(len(t.line))
+	t.queue([]rune{keyEscape, '[', 'J'})
+	t.moveCursorToPos(t.pos)
+}
+
+// handleMenuKey processes a key while the completion menu is shown. If the
+// key closes the menu and should be processed normally, handled is false.
+func (t *Terminal) handleMenuKey(key rune) (handled bool) {
+	switch key {
+	case keyTab:
+		t.selectCompletion(true)
+	case keyBackTab:
+		t.selectCompletion(false)
+	case keyEscape, keyCtrlG:
+		t.closeMenu(true)
+	case keyEnter:
+		t.closeMenu(false)
+	default:
+		t.closeMenu(false)
+		return false
+	}
+	return true
+}
+
+// drawMenu draws the completion menu in columns below the input line. If
+// there are more candidates than fit on the screen, the rows around the
+// selected candidate are shown.
+func (t *Terminal) drawMenu() {
+	m := t.menu
+	cells := make([][]rune, len(m.candidates))
+	colWidth := 0
+	for i, c := range m.candidates {
+		cell := c.Text
+		if c.Description != "" {
+			cell += "  (" + c.Description + ")"
+		}
+		cells[i] = []rune(cell)
+		if n := visualLength(cells[i]) + 2; n > colWidth {
+			colWidth = n
+		}
+	}
+	// Rows are kept shorter than the terminal so that they don't wrap.
+	if colWidth > t.termWidth-1 {
+		colWidth = t.termWidth - 1
+	}
+	if colWidth < 3 {
+		colWidth = 3
+	}
+	cols := (t.termWidth + 1) / colWidth
+	if cols < 1 {
+		cols = 1
+	}
+	rows := (len(cells) + cols - 1) / cols
+
+	// The input line and a row for the cursor to stay on screen remain
+	// visible.
+	maxRows := t.screenHeight() - t.maxLine - 2
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	first, shown := 0, rows
+	if rows > maxRows {
+		shown = maxRows - 1
+		if shown < 1 {
+			shown = 1
+		}
+		if m.selected >= 0 {
+			first = m.selected / cols / shown * shown
+		}
+	}
+
+	t.moveCursorToPos(len(t.line))
+	t.queue([]rune{keyEscape, '[', 'J'})
+	written := 0
+	for row := first; row < first+shown && row < rows; row++ {
+		t.queue([]rune("\r\n"))
+		written++
+		for col := 0; col < cols; col++ {
+			i := row*cols + col
+			if i >= len(cells) {
+				break
+			}
+			cell := cells[i]
+			n, width := fitGlyphs(cell, colWidth-2)
+			cell = cell[:n]
+			if i == m.selected && t.SupportsANSI() {
+				t.queue([]rune{keyEscape, '[', '7', 'm'})
+				t.queue(cell)
+				t.outBuf = append(t.outBuf, t.Escape.Reset...)
+			} else {
+				t.queue(cell)
+			}
+			if col < cols-1 && i < len(cells)-1 {
+				t.queue([]rune(strings.Repeat(" ", colWidth-width)))
+			}
+		}
+	}
+	if shown < rows {
+		t.queue([]rune("\r\n"))
+		written++
+		status := "rows " + strconv.Itoa(first+1) + "-" + strconv.Itoa(min(first+shown, rows)) + " of " + strconv.Itoa(rows)
+		if len(status) > t.termWidth-1 {
+			status = status[:max(t.termWidth-1, 0)]
+		}
+		t.queue([]rune(status))
+	}
+
+	// Move back to the end of the input line.
+	t.queue([]rune{'\r'})
+	t.move(written, 0, 0, t.cursorX)
+	t.moveCursorToPos(t.pos)
+}
@@ -0,0 +1,67 @@
+package term
+
+// Segment is a piece of the input line returned by a Terminal's Highlighter.
+type Segment struct {
+	Text string
+	// Style contains the escape codes written before Text, e.g.
+	// Terminal.Escape.Green. Attributes are reset after Text.
+	Style []byte
+}
+
+// writeInput writes the input line from position from to its end. The
+// cursor must be at from and is left at the end of the line. With a
+// Highlighter the whole line is written, as a change can restyle text before
+// the cursor.
+func (t *Terminal) writeInput(from int) {
+	if t.Highlighter == nil {
+		t.writeLine(t.line[from:])
+		return
+	}
+
+	segments := t.Highlighter(string(t.line))
+	if !segmentsMatch(segments, t.line) {
+		t.writeLine(t.line[from:])
+		return
+	}
+
+	t.moveCursorToPos(0)
+	for _, segment := range segments {
+		text := []rune(segment.Text)
+		if len(segment.Style) == 0 {
+			t.writeLine(text)
+			continue
+		}
+		// Styles are only written around the text between newlines,
+		// so the continuation prompt is written unstyled. Escape
+		// sequences are queued without advancing the cursor.
+		for len(text) > 0 {
+			if text[0] == '\n' {
+				t.writeLine(text[:1])
+				text = text[1:]
+				continue
+			}
+			n := indexRune(text, '\n')
+			if n < 0 {
+				n = len(text)
+			}
+			t.outBuf = append(t.outBuf, segment.Style...)
+			t.writeLine(text[:n])
+			t.outBuf = append(t.outBuf, t.Escape.Reset...)
+			text = text[n:]
+		}
+	}
+}
+
+// segmentsMatch returns true if the text of segments adds up to line.
+func segmentsMatch(segments []Segment, line []rune) bool {
+	pos := 0
+	for _, segment := range segments {
+		for _, r := range segment.Text {
+			if pos == len(line) || line[pos] != r {
+				return false
+			}
+			pos++
+		}
+	}
+	return pos == len(line)
+}
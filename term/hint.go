@@ -0,0 +1,92 @@
+package term
+
+import "strings"
+
+// hintStyle is the style hints are written in: bright black, which most
+// terminals show as grey.
+var hintStyle = []byte{keyEscape, '[', '9', '0', 'm'}
+
+// SetHistoryHints turns on or off hints from the history. When on, the most
+// recent history entry that starts with the input line is shown as a hint.
+func (t *Terminal) SetHistoryHints(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.historyHints = on
+}
+
+// findHint returns the hint for the current line, if any.
+func (t *Terminal) findHint() []rune {
+	if !t.echo || t.search != nil || t.menu != nil || t.pasteActive || !t.SupportsANSI() ||
+		len(t.line) == 0 || t.pos != len(t.line) {
+		return nil
+	}
+
+	line := string(t.line)
+	var hint string
+	if t.historyHints {
+		for i := 0; ; i++ {
+			entry, ok := t.history.NthPreviousEntry(i)
+			if !ok {
+				break
+			}
+			if len(entry) > len(line) && strings.HasPrefix(entry, line) {
+				hint = entry[len(line):]
+				break
+			}
+		}
+	}
+	if hint == "" && t.Hinter != nil {
+		hint = t.Hinter(line)
+	}
+	// Hints are kept to the line the cursor is on.
+	if i := strings.IndexByte(hint, '\n'); i >= 0 {
+		hint = hint[:i]
+	}
+	return []rune(hint)
+}
+
+// updateHint shows the hint for the current line, replacing the one shown.
+func (t *Terminal) updateHint() {
+	hint := t.findHint()
+	if len(hint) == 0 && len(t.hint) == 0 {
+		return
+	}
+	t.clearHint()
+	t.hint = hint
+	t.writeHint()
+}
+
+// writeHint writes the hint after the input line, leaving the cursor where
+// it is.
+func (t *Terminal) writeHint() {
+	if len(t.hint) == 0 {
+		return
+	}
+	t.moveCursorToPos(len(t.line))
+	t.outBuf = append(t.outBuf, hintStyle...)
+	t.writeLine(t.hint)
+	t.outBuf = append(t.outBuf, t.Escape.Reset...)
+	t.moveCursorToPos(t.pos)
+}
+
+// clearHint erases the hint shown after the input line.
+func (t *Terminal) clearHint() {
+	if len(t.hint) == 0 {
+		return
+	}
+	t.hint = nil
+	t.moveCursorToPos(len(t.line))
+	t.queue([]rune{keyEscape, '[', 'J'})
+	t.moveCursorToPos(t.pos)
+}
+
+// acceptHint appends the hint to the input line.
+func (t *Terminal) acceptHint() {
+	if len(t.hint) == 0 {
+		return
+	}
+	hint := t.hint
+	t.clearHint()
+	t.insertRunes(hint)
+}
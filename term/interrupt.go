@@ -0,0 +1,40 @@
+package term
+
+import "errors"
+
+// ErrInterrupted is returned from ReadLine when Ctrl-C is pressed and line
+// interrupts are enabled with SetLineInterrupt.
+var ErrInterrupted = errors.New("terminal: line interrupted")
+
+// SetLineInterrupt sets whether Ctrl-C only discards the line being edited.
+// When on, ReadLine shows ^C, moves to a new line and returns ErrInterrupted
+// so the caller can prompt again. When off, which is the default, ReadLine
+// returns io.EOF as it does for Ctrl-D.
+func (t *Terminal) SetLineInterrupt(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.lineInterrupt = on
+}
+
+// interruptLine discards the line being edited after Ctrl-C was pressed and
+// keeps rest, the input following the Ctrl-C, for the next ReadLine.
+func (t *Terminal) interruptLine(rest []byte) {
+	t.clearHint()
+	t.moveCursorToPos(len(t.line))
+	if t.echo {
+		t.queue([]rune("^C"))
+	}
+	t.queue([]rune("\r\n"))
+	t.moveProgressBelow()
+	t.line = t.line[:0]
+	t.pos = 0
+	t.cursorX = 0
+	t.cursorY = 0
+	t.maxLine = 0
+	t.historyIndex = -1
+
+	t.setRemainder(rest)
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+}
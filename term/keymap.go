@@ -0,0 +1,516 @@
+package term
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// actionKeys maps the names of the editor actions to the key that runs the
+// action in handleKey. The names are the ones used by GNU readline.
+var actionKeys = map[string]rune{
+	"beginning-of-line":       keyHome,
+	"end-of-line":             keyEnd,
+	"backward-char":           keyLeft,
+	"forward-char":            keyRight,
+	"backward-word":           keyAltLeft,
+	"forward-word":            keyAltRight,
+	"previous-history":        keyUp,
+	"next-history":            keyDown,
+	"history-search-backward": keyHistorySearchBackward,
+	"history-search-forward":  keyHistorySearchForward,
+	"reverse-search-history":  keyCtrlR,
+	"forward-search-history":  keyCtrlS,
+	"accept-line":             keyEnter,
+	"backward-delete-char":    keyBackspace,
+	"delete-char":             keyDelete,
+	"kill-line":               keyDeleteLine,
+	"unix-line-discard":       keyCtrlU,
+	"kill-word":               keyAltD,
+	"backward-kill-word":      keyDeleteWord,
+	"unix-word-rubout":        keyDeleteWord,
+	"yank":                    keyCtrlY,
+	"yank-pop":                keyAltY,
+	"transpose-chars":         keyCtrlT,
+	"upcase-word":             keyAltU,
+	"downcase-word":           keyAltL,
+	"capitalize-word":         keyAltC,
+	"clear-screen":            keyClearScreen,
+	"undo":                    keyUndo,
+	"redo":                    keyRedo,
+	"complete":                keyTab,
+}
+
+// defaultKeymap contains the key bindings of emacs mode. The keys are the
+// ones looked up by keymapKey.
+var defaultKeymap = map[rune]string{
+	ctrlKey('a'): "beginning-of-line",
+	keyHome:      "beginning-of-line",
+	ctrlKey('e'): "end-of-line",
+	keyEnd:       "end-of-line",
+	ctrlKey('b'): "backward-char",
+	keyLeft:      "backward-char",
+	ctrlKey('f'): "forward-char",
+	keyRight:     "forward-char",
+	keyAlt + 'b': "backward-word",
+	keyAltLeft:   "backward-word",
+	keyCtrlLeft:  "backward-word",
+	keyAlt + 'f': "forward-word",
+	keyAltRight:  "forward-word",
+	keyCtrlRight: "forward-word",
+	ctrlKey('p'): "previous-history",
+	keyUp:        "previous-history",
+	ctrlKey('n'): "next-history",
+	keyDown:      "next-history",
+	keyCtrlR:     "reverse-search-history",
+	keyCtrlS:     "forward-search-history",
+	keyEnter:     "accept-line",
+	ctrlKey('h'): "backward-delete-char",
+	keyBackspace: "backward-delete-char",
+	keyCtrlD:     "delete-char",
+	keyDelete:    "delete-char",
+	ctrlKey('k'): "kill-line",
+	keyCtrlU:     "unix-line-discard",
+	keyAlt + 'd': "kill-word",
+	ctrlKey('w'): "unix-word-rubout",
+	keyCtrlY:     "yank",
+	keyAlt + 'y': "yank-pop",
+	keyCtrlT:     "transpose-chars",
+	keyAlt + 'u': "upcase-word",
+	keyAlt + 'l': "downcase-word",
+	keyAlt + 'c': "capitalize-word",
+	ctrlKey('l'): "clear-screen",
+	keyUndo:      "undo",
+	keyAlt + '/': "redo",
+	keyTab:       "complete",
+}
+
+// ctrlKey returns the key looked up by a keymap for c pressed with Control.
+func ctrlKey(c byte) rune {
+	return rune(controlByte(c))
+}
+
+// keymapKey returns the key a keymap looks up for key, which bytesToKey
+// decoded from seq. Control characters and escape followed by a character
+// are looked up as they are typed rather than as the editor keys bytesToKey
+// turns them into, so Ctrl-A and Home, or Meta-b and Alt-Left, can be bound
+// to different actions.
+func keymapKey(key rune, seq []byte) rune {
+	switch {
+	case len(seq) == 1 && seq[0] < ' ':
+		return rune(seq[0])
+	case len(seq) == 2 && seq[0] == keyEscape && isAltChar(seq[1]):
+		return keyAlt + rune(seq[1])
+	}
+	return key
+}
+
+// keymapCode returns the key a keymap looks up for the key event.
+func keymapCode(e KeyEvent) rune {
+	r := rune(e.Key)
+	switch {
+	case e.Mod == ModCtrl && r < utf8.RuneSelf && (unicode.IsLetter(r) || strings.ContainsRune("@[\\]^_", r)):
+		return ctrlKey(byte(r))
+	case e.Mod == ModAlt && r < utf8.RuneSelf && isAltChar(byte(r)):
+		return keyAlt + r
+	}
+	return e.code()
+}
+
+// KeymapActions returns the names of the editor actions that keys can be
+// bound to.
+func KeymapActions() []string {
+	names := make([]string, 0, len(actionKeys))
+	for name := range actionKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrUnknownAction is returned when binding a key to an action that doesn't
+// exist.
+var ErrUnknownAction = errors.New("terminal: unknown editor action")
+
+// Keymap maps keys to named editor actions such as beginning-of-line or
+// kill-word. A keymap is used by a Terminal in emacs mode after it is set
+// with SetKeymap, and may be changed while the terminal is in use.
+//
+// Keys are looked up as they are typed, so keys that run the same action by
+// default, such as Ctrl-A and Home, can be bound to different actions.
+type Keymap struct {
+	lock sync.Mutex
+	keys map[rune]string
+}
+
+// NewKeymap returns a keymap with the default emacs bindings.
+func NewKeymap() *Keymap {
+	m := &Keymap{keys: make(map[rune]string, len(defaultKeymap))}
+	for key, action := range defaultKeymap {
+		m.keys[key] = action
+	}
+	return m
+}
+
+// Bind binds key to the named action.
+func (m *Keymap) Bind(key KeyEvent, action string) error {
+	code := keymapCode(key)
+	if code == utf8.RuneError {
+		return ErrKeyNotBindable
+	}
+	return m.bind(code, action)
+}
+
+// BindSequence binds the key sending seq to the named action. seq is written
+// as a quoted key sequence in an inputrc file, for example \C-a or \e[3~.
+func (m *Keymap) BindSequence(seq, action string) error {
+	b, err := parseKeySequence(seq)
+	if err != nil {
+		return err
+	}
+	code, err := sequenceKey(b)
+	if err != nil {
+		return err
+	}
+	return m.bind(code, action)
+}
+
+func (m *Keymap) bind(code rune, action string) error {
+	if _, ok := actionKeys[action]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.keys[code] = action
+	return nil
+}
+
+// Unbind removes the binding of key, so the key does nothing.
+func (m *Keymap) Unbind(key KeyEvent) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.keys, keymapCode(key))
+}
+
+// Action returns the name of the action bound to key, or an empty string if
+// the key isn't bound.
+func (m *Keymap) Action(key KeyEvent) string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.keys[keymapCode(key)]
+}
+
+// translate returns the key that runs the action bound to typed, the key
+// looked up by keymapKey for key. Keys without a binding are returned as
+// they were decoded, unless they have a default binding that was removed, in
+// which case bound is false.
+func (m *Keymap) translate(typed, key rune) (rune, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if action, ok := m.keys[typed]; ok {
+		return actionKeys[action], true
+	}
+	if _, ok := defaultKeymap[typed]; ok {
+		return key, false
+	}
+	return key, true
+}
+
+// SetKeymap sets the keymap used in emacs mode. A nil keymap restores the
+// default bindings.
+func (t *Terminal) SetKeymap(m *Keymap) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.keymap = m
+}
+
+// Keymap returns the keymap set with SetKeymap, or nil if the default
+// bindings are used.
+func (t *Terminal) Keymap() *Keymap {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.keymap
+}
+
+// KeymapFile returns the path of the user's key binding file. It is the file
+// named by the INPUTRC environment variable, or .inputrc in the home
+// directory.
+func KeymapFile() string {
+	if path := os.Getenv("INPUTRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".inputrc")
+}
+
+// LoadFile reads key bindings from the inputrc file at path. See Load for
+// the format.
+func (m *Keymap) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.Load(f)
+}
+
+// Load reads key bindings in the format of a readline inputrc file:
+//
+//	# comment
+//	"\C-a": end-of-line
+//	"\e[3~": delete-char
+//	Meta-d: kill-word
+//	Control-w: backward-kill-word
+//
+// Variable settings, conditional constructs, bindings to actions or macros
+// that the terminal doesn't support and bindings of key sequences that are
+// not a single key, such as escape followed by an arrow key, are ignored, so
+// a file shared with other readline programs can be loaded. Lines that can't
+// be parsed are skipped and reported in the returned error, after the other
+// bindings have been loaded.
+func (m *Keymap) Load(r io.Reader) error {
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '$' || strings.HasPrefix(line, "set ") {
+			continue
+		}
+
+		seq, action, err := splitBinding(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", n, err))
+			continue
+		}
+		if _, ok := actionKeys[action]; !ok {
+			continue
+		}
+		code, err := sequenceKey(seq)
+		if err != nil {
+			continue
+		}
+		m.bind(code, action)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// splitBinding splits an inputrc binding into the key sequence and the
+// action.
+func splitBinding(line string) (seq []byte, action string, err error) {
+	var key string
+	if line[0] == '"' {
+		end := 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return nil, "", errors.New("unterminated key sequence")
+		}
+		if seq, err = parseKeySequence(line[1:end]); err != nil {
+			return nil, "", err
+		}
+		key, line = "", line[end+1:]
+	} else {
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, "", errors.New("missing ':'")
+		}
+		key, line = line[:i], line[i:]
+		if seq, err = parseKeyName(key); err != nil {
+			return nil, "", err
+		}
+	}
+
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, ":") {
+		return nil, "", errors.New("missing ':'")
+	}
+	action = strings.TrimSpace(line[1:])
+	if i := strings.IndexAny(action, " \t"); i >= 0 {
+		action = action[:i]
+	}
+	return seq, action, nil
+}
+
+// keyNameBytes maps the key names of inputrc files to the bytes they send.
+var keyNameBytes = map[string]byte{
+	"del":     keyBackspace,
+	"rubout":  keyBackspace,
+	"esc":     keyEscape,
+	"escape":  keyEscape,
+	"lfd":     '\n',
+	"newline": '\n',
+	"ret":     keyEnter,
+	"return":  keyEnter,
+	"spc":     ' ',
+	"space":   ' ',
+	"tab":     keyTab,
+}
+
+// parseKeyName parses a key name such as Control-u or Meta-Rubout.
+func parseKeyName(name string) ([]byte, error) {
+	var meta, control bool
+	for {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, "control-"):
+			control, name = true, name[len("control-"):]
+			continue
+		case strings.HasPrefix(lower, "meta-"):
+			meta, name = true, name[len("meta-"):]
+			continue
+		case strings.HasPrefix(lower, "c-"):
+			control, name = true, name[2:]
+			continue
+		case strings.HasPrefix(lower, "m-"):
+			meta, name = true, name[2:]
+			continue
+		}
+		break
+	}
+
+	var c byte
+	if b, ok := keyNameBytes[strings.ToLower(name)]; ok {
+		c = b
+	} else if len(name) == 1 {
+		c = name[0]
+	} else {
+		return nil, fmt.Errorf("unknown key name %q", name)
+	}
+	if control {
+		c = controlByte(c)
+	}
+	if meta {
+		return []byte{keyEscape, c}, nil
+	}
+	return []byte{c}, nil
+}
+
+// controlByte returns the byte sent for c pressed with Control.
+func controlByte(c byte) byte {
+	if c == '?' {
+		return keyBackspace
+	}
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	return c & 0x1f
+}
+
+// parseKeySequence parses the escapes of a quoted inputrc key sequence.
+func parseKeySequence(s string) ([]byte, error) {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b = append(b, s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return nil, errors.New("incomplete escape in key sequence")
+		}
+		switch c := s[i]; c {
+		case 'C', 'M':
+			if i+2 >= len(s) || s[i+1] != '-' {
+				return nil, fmt.Errorf("incomplete \\%c- escape in key sequence", c)
+			}
+			rest, err := parseKeySequence(s[i+2:])
+			if err != nil || len(rest) == 0 {
+				return nil, fmt.Errorf("incomplete \\%c- escape in key sequence", c)
+			}
+			if c == 'C' {
+				rest[0] = controlByte(rest[0])
+			} else {
+				rest = append([]byte{keyEscape}, rest...)
+			}
+			return append(b, rest...), nil
+		case 'e':
+			b = append(b, keyEscape)
+		case 'a':
+			b = append(b, '\a')
+		case 'b':
+			b = append(b, '\b')
+		case 'd':
+			b = append(b, keyBackspace)
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'v':
+			b = append(b, '\v')
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+				j++
+			}
+			n, err := strconv.ParseUint(s[i+1:j], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid \\x escape in key sequence")
+			}
+			b = append(b, byte(n))
+			i = j - 1
+		default:
+			if c >= '0' && c <= '7' {
+				j := i
+				for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+					j++
+				}
+				n, err := strconv.ParseUint(s[i:j], 8, 8)
+				if err != nil {
+					return nil, fmt.Errorf("invalid octal escape in key sequence")
+				}
+				b = append(b, byte(n))
+				i = j - 1
+				continue
+			}
+			b = append(b, c)
+		}
+	}
+	return b, nil
+}
+
+// sequenceKey decodes a key sequence that must consist of exactly one key
+// and returns the key a keymap looks up for it.
+func sequenceKey(b []byte) (rune, error) {
+	key, rest := bytesToKey(b, false)
+	if key == utf8.RuneError && len(b) == 1 && b[0] == keyEscape {
+		key, rest = keyEscape, nil
+	}
+	if key == utf8.RuneError || len(rest) > 0 {
+		return 0, fmt.Errorf("key sequence %q is not a single key", b)
+	}
+	if key == keyUnknown {
+		return 0, fmt.Errorf("key sequence %q is not a key", b)
+	}
+	return keymapKey(key, b), nil
+}
@@ -0,0 +1,177 @@
+package term
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeymapLoad(t *testing.T) {
+	const inputrc = `# key bindings
+set editing-mode emacs
+$if term=xterm
+"\C-a": end-of-line
+$endif
+"\e[5~": history-search-backward
+"\e[6~": history-search-forward
+Meta-b: kill-word
+Control-w: backward-kill-word
+"\C-x\C-r": re-read-init-file
+"\C-o": "macro text"
+`
+	m := NewKeymap()
+	if err := m.Load(strings.NewReader(inputrc)); err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+
+	tests := []struct {
+		key    KeyEvent
+		action string
+	}{
+		{KeyEvent{Key: 'a', Mod: ModCtrl}, "end-of-line"},
+		{KeyEvent{Key: KeyPageUp}, "history-search-backward"},
+		{KeyEvent{Key: KeyPageDown}, "history-search-forward"},
+		{KeyEvent{Key: 'b', Mod: ModAlt}, "kill-word"},
+		{KeyEvent{Key: KeyLeft, Mod: ModAlt}, "backward-word"},
+		{KeyEvent{Key: KeyHome}, "beginning-of-line"},
+		{KeyEvent{Key: 'w', Mod: ModCtrl}, "backward-kill-word"},
+		{KeyEvent{Key: 'e', Mod: ModCtrl}, "end-of-line"},
+		{KeyEvent{Key: 'o', Mod: ModCtrl}, ""},
+	}
+	for _, test := range tests {
+		if got := m.Action(test.key); got != test.action {
+			t.Errorf("%v is bound to %q, expected %q", test.key, got, test.action)
+		}
+	}
+}
+
+func TestKeymapLoadErrors(t *testing.T) {
+	for _, inputrc := range []string{
+		`"\C-a end-of-line`,
+		`"\C-a" end-of-line`,
+		`Hyper-a: end-of-line`,
+	} {
+		if err := NewKeymap().Load(strings.NewReader(inputrc)); err == nil {
+			t.Errorf("loading %q did not fail", inputrc)
+		}
+	}
+}
+
+func TestKeymapLoadSystemInputrc(t *testing.T) {
+	// An excerpt of the /etc/inputrc of Debian.
+	const inputrc = `# /etc/inputrc - global inputrc for libreadline
+set input-meta on
+set output-meta on
+"\e[1~": beginning-of-line
+"\e[4~": end-of-line
+"\e[5~": beginning-of-history
+"\e[6~": end-of-history
+"\e[3~": delete-char
+"\e[2~": quoted-insert
+# mappings for Ctrl-left-arrow and Ctrl-right-arrow for word moving
+"\e[1;5C": forward-word
+"\e[1;5D": backward-word
+"\e[5C": forward-word
+"\e[5D": backward-word
+"\e\e[C": forward-word
+"\e\e[D": backward-word
+`
+	m := NewKeymap()
+	m.Unbind(KeyEvent{Key: KeyDelete})
+	m.Unbind(KeyEvent{Key: KeyRight, Mod: ModCtrl})
+	if err := m.Load(strings.NewReader(inputrc)); err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+
+	tests := []struct {
+		key    KeyEvent
+		action string
+	}{
+		{KeyEvent{Key: KeyDelete}, "delete-char"},
+		{KeyEvent{Key: KeyRight, Mod: ModCtrl}, "forward-word"},
+		{KeyEvent{Key: KeyRight}, "forward-char"},
+		{KeyEvent{Key: KeyLeft}, "backward-char"},
+		{KeyEvent{Key: KeyEscape}, ""},
+	}
+	for _, test := range tests {
+		if got := m.Action(test.key); got != test.action {
+			t.Errorf("%v is bound to %q, expected %q", test.key, got, test.action)
+		}
+	}
+}
+
+func TestKeymapLoadSkipsBadLines(t *testing.T) {
+	m := NewKeymap()
+	err := m.Load(strings.NewReader("\"\\C-a end-of-line\n\"\\C-e\": beginning-of-line\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Load returned %v, expected an error for line 1", err)
+	}
+	if got := m.Action(KeyEvent{Key: 'e', Mod: ModCtrl}); got != "beginning-of-line" {
+		t.Errorf("Ctrl-E is bound to %q, expected beginning-of-line", got)
+	}
+}
+
+func TestKeymapBind(t *testing.T) {
+	m := NewKeymap()
+	if err := m.Bind(KeyEvent{Key: KeyF2}, "no-such-action"); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("binding an unknown action returned %v", err)
+	}
+	if err := m.BindSequence(`\eOQ`, "kill-line"); err != nil {
+		t.Fatalf("BindSequence returned %v", err)
+	}
+	if got := m.Action(KeyEvent{Key: KeyF2}); got != "kill-line" {
+		t.Errorf("F2 is bound to %q, expected kill-line", got)
+	}
+	if err := m.BindSequence(`\C-x\C-u`, "undo"); err == nil {
+		t.Errorf("binding a sequence of two keys did not fail")
+	}
+}
+
+func TestKeymapKeyPresses(t *testing.T) {
+	m := NewKeymap()
+	m.Bind(KeyEvent{Key: 'a', Mod: ModCtrl}, "end-of-line")
+	m.Bind(KeyEvent{Key: KeyF2}, "beginning-of-line")
+	m.Bind(KeyEvent{Key: KeyPageUp}, "history-search-backward")
+	m.Bind(KeyEvent{Key: KeyPageDown}, "history-search-forward")
+	m.BindSequence(`\C-p`, "history-search-backward")
+	m.Unbind(KeyEvent{Key: 'k', Mod: ModCtrl})
+
+	tests := []struct {
+		in   string
+		line string
+	}{
+		// Ctrl-A is rebound to end-of-line, Home is not.
+		{"bc\x01\x1bOQa\x01d\r", "abcd"},
+		{"bc\x1b[Ha\x01d\r", "abcd"},
+		// Ctrl-P searches the history, Up still recalls the previous line.
+		{"gi\x10\r", "git log"},
+		{"gi\x1b[A\r", "git log"},
+		{"gi\x10\x10\r", "git status"},
+		{"gi\x1b[A\x1b[A\r", "ls"},
+		// Ctrl-K is unbound and does nothing.
+		{"abc\x1bOQ\x0b\r", "abc"},
+		// History search by the text before the cursor.
+		{"gi\x1b[5~\r", "git log"},
+		{"gi\x1b[5~\x1b[5~\r", "git status"},
+		{"gi\x1b[5~\x1b[5~\x1b[6~\r", "git log"},
+		{"gi\x1b[5~\x1b[6~\r", "gi"},
+	}
+	for i, test := range tests {
+		c := &MockTerminal{
+			toSend:       []byte(test.in),
+			bytesPerRead: 1,
+		}
+		ss := NewTerminal(c, "> ")
+		ss.AddHistory("git status")
+		ss.AddHistory("ls")
+		ss.AddHistory("git log")
+		ss.SetKeymap(m)
+		line, err := ss.ReadLine()
+		if err != nil {
+			t.Fatalf("test %d: ReadLine returned %v", i, err)
+		}
+		if line != test.line {
+			t.Errorf("test %d: line was %q, expected %q", i, line, test.line)
+		}
+	}
+}
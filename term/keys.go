@@ -0,0 +1,412 @@
+package term
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Key identifies a key on the keyboard. Printable keys are their rune,
+// control characters their ASCII code and other keys one of the Key
+// constants.
+type Key rune
+
+// Keys that don't produce a character.
+const (
+	KeyUnknown   Key = keyUnknown
+	KeyTab       Key = keyTab
+	KeyEnter     Key = keyEnter
+	KeyEscape    Key = keyEscape
+	KeyBackspace Key = keyBackspace
+	KeyUp        Key = keyUp
+	KeyDown      Key = keyDown
+	KeyLeft      Key = keyLeft
+	KeyRight     Key = keyRight
+	KeyHome      Key = keyHome
+	KeyEnd       Key = keyEnd
+	KeyInsert    Key = keyInsert
+	KeyDelete    Key = keyDelete
+	KeyPageUp    Key = keyPageUp
+	KeyPageDown  Key = keyPageDown
+	KeyF1        Key = keyF1
+	KeyF2        Key = keyF2
+	KeyF3        Key = keyF3
+	KeyF4        Key = keyF4
+	KeyF5        Key = keyF5
+	KeyF6        Key = keyF6
+	KeyF7        Key = keyF7
+	KeyF8        Key = keyF8
+	KeyF9        Key = keyF9
+	KeyF10       Key = keyF10
+	KeyF11       Key = keyF11
+	KeyF12       Key = keyF12
+)
+
+var keyNames = map[Key]string{
+	KeyUnknown:   "Unknown",
+	KeyTab:       "Tab",
+	KeyEnter:     "Enter",
+	KeyEscape:    "Escape",
+	KeyBackspace: "Backspace",
+	KeyUp:        "Up",
+	KeyDown:      "Down",
+	KeyLeft:      "Left",
+	KeyRight:     "Right",
+	KeyHome:      "Home",
+	KeyEnd:       "End",
+	KeyInsert:    "Insert",
+	KeyDelete:    "Delete",
+	KeyPageUp:    "PageUp",
+	KeyPageDown:  "PageDown",
+}
+
+func (k Key) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	if k >= KeyF1 && k <= KeyF12 {
+		return "F" + strconv.Itoa(int(k-KeyF1)+1)
+	}
+	if k < 32 {
+		return "Ctrl-" + string(rune(k)+'a'-1)
+	}
+	return string(rune(k))
+}
+
+// KeyMod is a set of modifier keys held down with a key. The values match
+// the modifier parameter of xterm key sequences minus one.
+type KeyMod uint8
+
+// Modifier keys.
+const (
+	ModShift KeyMod = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// KeyEvent is a key pressed together with modifier keys.
+type KeyEvent struct {
+	Key Key
+	Mod KeyMod
+}
+
+func (e KeyEvent) String() string {
+	var b strings.Builder
+	if e.Mod&ModCtrl != 0 {
+		b.WriteString("Ctrl-")
+	}
+	if e.Mod&ModAlt != 0 {
+		b.WriteString("Alt-")
+	}
+	if e.Mod&ModShift != 0 {
+		b.WriteString("Shift-")
+	}
+	b.WriteString(e.Key.String())
+	return b.String()
+}
+
+// Special keys pressed with modifiers are decoded to the key plus the
+// modifiers shifted by keyModShift. All special keys must therefore be less
+// than keyUnknown+1<<keyModShift.
+const keyModShift = 6
+
+const (
+	keyCtrlLeft  rune = keyLeft + rune(ModCtrl)<<keyModShift
+	keyCtrlRight rune = keyRight + rune(ModCtrl)<<keyModShift
+)
+
+// withMod returns the special key with the modifiers mod.
+func withMod(key rune, mod KeyMod) rune {
+	if key < keyUnknown || key >= keyUnknown+1<<keyModShift {
+		return key
+	}
+	return key + rune(mod&(ModShift|ModAlt|ModCtrl))<<keyModShift
+}
+
+// splitMod returns the key and modifiers of a decoded key.
+func splitMod(key rune) (rune, KeyMod) {
+	if key < keyUnknown || key >= keyUnknown+8<<keyModShift {
+		return key, 0
+	}
+	offset := key - keyUnknown
+	return keyUnknown + offset&(1<<keyModShift-1), KeyMod(offset >> keyModShift)
+}
+
+// Escape followed by a printable ASCII character that has no editor key of
+// its own is decoded to keyAlt plus the character. The keys stay below the
+// end of the surrogate area that isPrintable excludes.
+const keyAlt rune = 0xdb00
+
+// isAltChar returns true if escape followed by c is decoded to keyAlt plus c.
+// Escape followed by [ or O starts a control sequence.
+func isAltChar(c byte) bool {
+	return c >= ' ' && c < keyBackspace && c != '[' && c != 'O'
+}
+
+// tildeKeys maps the number of a "CSI number ~" sequence to its key.
+var tildeKeys = map[int]rune{
+	1:  keyHome,
+	2:  keyInsert,
+	3:  keyDelete,
+	4:  keyEnd,
+	5:  keyPageUp,
+	6:  keyPageDown,
+	7:  keyHome,
+	8:  keyEnd,
+	11: keyF1,
+	12: keyF2,
+	13: keyF3,
+	14: keyF4,
+	15: keyF5,
+	17: keyF6,
+	18: keyF7,
+	19: keyF8,
+	20: keyF9,
+	21: keyF10,
+	23: keyF11,
+	24: keyF12,
+}
+
+// finalKeys maps the final byte of "CSI letter" and "SS3 letter" sequences
+// to its key.
+var finalKeys = map[byte]rune{
+	'A': keyUp,
+	'B': keyDown,
+	'C': keyRight,
+	'D': keyLeft,
+	'H': keyHome,
+	'F': keyEnd,
+	'P': keyF1,
+	'Q': keyF2,
+	'R': keyF3,
+	'S': keyF4,
+}
+
+// decodeEscape decodes the CSI (ESC [) or SS3 (ESC O) sequence at the start
+// of b. It returns utf8.RuneError if the sequence is not complete yet and
+// keyUnknown for sequences that are not keys.
+func decodeEscape(b []byte) (rune, []byte) {
+	if len(b) < 3 {
+		return utf8.RuneError, b
+	}
+	if b[1] == 'O' {
+		if key, ok := finalKeys[b[2]]; ok {
+			return key, b[3:]
+		}
+		return keyUnknown, b[3:]
+	}
+
+	if b[2] == '[' {
+		// The Linux console sends ESC [ [ A to ESC [ [ E for F1 to F5.
+		if len(b) < 4 {
+			return utf8.RuneError, b
+		}
+		if b[3] >= 'A' && b[3] <= 'E' {
+			return keyF1 + rune(b[3]-'A'), b[4:]
+		}
+		return keyUnknown, b[4:]
+	}
+
+	// Parameter and intermediate bytes are followed by a final byte.
+	i := 2
+	for i < len(b) && b[i] >= 0x20 && b[i] <= 0x3f {
+		i++
+	}
+	if i == len(b) {
+		return utf8.RuneError, b
+	}
+	params, final, rest := string(b[2:i]), b[i], b[i+1:]
+	if strings.HasPrefix(params, "<") && (final == 'M' || final == 'm') {
+		return keyMouse, rest
+	}
+
+	var args []int
+	for _, p := range strings.Split(params, ";") {
+		n, err := strconv.Atoi(p)
+		if err != nil && p != "" {
+			return keyUnknown, rest
+		}
+		args = append(args, n)
+	}
+	var mod KeyMod
+	if len(args) > 1 && args[1] > 1 {
+		m := args[1] - 1
+		mod = KeyMod(m) & (ModShift | ModAlt | ModCtrl)
+		if m&8 != 0 {
+			// Meta is handled like Alt.
+			mod |= ModAlt
+		}
+	}
+
+	var key rune
+	switch final {
+	case '~':
+		switch args[0] {
+		case 200:
+			return keyPasteStart, rest
+		case 201:
+			return keyUnknown, rest
+		}
+		var ok bool
+		if key, ok = tildeKeys[args[0]]; !ok {
+			return keyUnknown, rest
+		}
+	case 'Z':
+		return keyBackTab, rest
+	default:
+		var ok bool
+		// A number other than 1 before the modifier is a count, as
+		// in the cursor movement CSI 5 C, not a key.
+		if key, ok = finalKeys[final]; !ok || len(args) > 2 || args[0] > 1 {
+			return keyUnknown, rest
+		}
+	}
+
+	switch {
+	case mod == ModAlt && key == keyLeft:
+		return keyAltLeft, rest
+	case mod == ModAlt && key == keyRight:
+		return keyAltRight, rest
+	}
+	return withMod(key, mod), rest
+}
+
+// DecodeKey decodes the key sequence at the start of b and returns the key
+// event and the remaining input. ok is false if b doesn't start with a
+// complete key sequence. Sequences that are not keys are decoded to
+// KeyUnknown.
+func DecodeKey(b []byte) (event KeyEvent, rest []byte, ok bool) {
+	if len(b) == 0 {
+		return KeyEvent{}, b, false
+	}
+	if c := b[0]; c < 32 && c != keyTab && c != keyEnter && c != keyEscape {
+		return KeyEvent{Key: Key(c) + '@', Mod: ModCtrl}.lower(), b[1:], true
+	}
+
+	key, rest := bytesToKey(b, false)
+	if key == utf8.RuneError {
+		return KeyEvent{}, b, false
+	}
+	return keyEventOf(key), rest, true
+}
+
+// lower reports control letters in lower case, as they are typed.
+func (e KeyEvent) lower() KeyEvent {
+	if e.Key >= 'A' && e.Key <= 'Z' {
+		e.Key += 'a' - 'A'
+	}
+	return e
+}
+
+// keyEventOf returns the key event for a key decoded by bytesToKey.
+func keyEventOf(key rune) KeyEvent {
+	switch key {
+	case keyAltLeft:
+		return KeyEvent{Key: KeyLeft, Mod: ModAlt}
+	case keyAltRight:
+		return KeyEvent{Key: KeyRight, Mod: ModAlt}
+	case keyBackTab:
+		return KeyEvent{Key: KeyTab, Mod: ModShift}
+	case keyRedo:
+		return KeyEvent{Key: '/', Mod: ModAlt}
+	}
+	if letter, ok := altLetter(key); ok {
+		return KeyEvent{Key: Key(letter), Mod: ModAlt}
+	}
+	base, mod := splitMod(key)
+	if base >= keyUnknown {
+		if _, named := keyNames[Key(base)]; !named && (base < keyF1 || base > keyF12) {
+			// Editor keys such as keyPasteStart have no key event.
+			return KeyEvent{Key: KeyUnknown}
+		}
+	}
+	return KeyEvent{Key: Key(base), Mod: mod}
+}
+
+// code returns the key decoded by bytesToKey for the key event, or
+// utf8.RuneError if no key sequence is decoded to it.
+func (e KeyEvent) code() rune {
+	r := rune(e.Key)
+	switch {
+	case e.Key == KeyTab && e.Mod == ModShift:
+		return keyBackTab
+	case r >= keyUnknown:
+		if e.Mod == ModAlt && r == keyLeft {
+			return keyAltLeft
+		}
+		if e.Mod == ModAlt && r == keyRight {
+			return keyAltRight
+		}
+		return withMod(r, e.Mod)
+	case e.Mod == 0:
+		return r
+	case e.Mod == ModShift && unicode.IsLetter(r):
+		return unicode.ToUpper(r)
+	case e.Mod == ModCtrl && r < utf8.RuneSelf && (unicode.IsLetter(r) || strings.ContainsRune("@[\\]^_", r)):
+		key, _ := bytesToKey([]byte{byte(unicode.ToUpper(r)) & 0x1f}, false)
+		return key
+	case e.Mod == ModAlt && r == '/':
+		return keyRedo
+	case e.Mod == ModAlt:
+		for key, letter := range altKeyLetters {
+			if letter == r {
+				return key
+			}
+		}
+		if r < utf8.RuneSelf && isAltChar(byte(r)) {
+			return keyAlt + r
+		}
+	}
+	return utf8.RuneError
+}
+
+// KeyAction is called when a bound key is pressed. It gets the line being
+// edited and the cursor position in bytes, and returns the new line and
+// cursor position. The terminal is unlocked while it runs, so it may write
+// output, which is shown above the prompt.
+type KeyAction func(line string, pos int) (newLine string, newPos int)
+
+// ErrKeyNotBindable is returned by BindKey for key events that the terminal
+// cannot tell apart from other keys, such as Shift with most special keys.
+var ErrKeyNotBindable = errors.New("terminal: key cannot be bound")
+
+// BindKey calls action whenever key is pressed while reading a line, instead
+// of the default behavior of the key. A nil action removes the binding.
+// Ctrl-C and Ctrl-D on an empty line cannot be bound.
+func (t *Terminal) BindKey(key KeyEvent, action KeyAction) error {
+	code := key.code()
+	if code == utf8.RuneError {
+		return ErrKeyNotBindable
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if action == nil {
+		delete(t.bindings, code)
+		return nil
+	}
+	if t.bindings == nil {
+		t.bindings = make(map[rune]KeyAction)
+	}
+	t.bindings[code] = action
+	return nil
+}
+
+// runKeyAction calls a bound action and updates the line with its result.
+func (t *Terminal) runKeyAction(action KeyAction) {
+	prefix := string(t.line[:t.pos])
+	line := prefix + string(t.line[t.pos:])
+
+	t.lock.Unlock()
+	newLine, newPos := action(line, len(prefix))
+	t.lock.Lock()
+
+	if newLine == line && newPos == len(prefix) {
+		return
+	}
+	newPos = max(0, min(newPos, len(newLine)))
+	t.setLine([]rune(newLine), utf8.RuneCountInString(newLine[:newPos]))
+}
@@ -0,0 +1,136 @@
+package term
+
+import (
+	"testing"
+)
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		in    string
+		event KeyEvent
+		rest  string
+	}{
+		{"a", KeyEvent{Key: 'a'}, ""},
+		{"\x01x", KeyEvent{Key: 'a', Mod: ModCtrl}, "x"},
+		{"\t", KeyEvent{Key: KeyTab}, ""},
+		{"\x7f", KeyEvent{Key: KeyBackspace}, ""},
+		{"\x1b[A", KeyEvent{Key: KeyUp}, ""},
+		{"\x1bOB", KeyEvent{Key: KeyDown}, ""},
+		{"\x1b[3~", KeyEvent{Key: KeyDelete}, ""},
+		{"\x1b[2~", KeyEvent{Key: KeyInsert}, ""},
+		{"\x1b[5~a", KeyEvent{Key: KeyPageUp}, "a"},
+		{"\x1b[6~", KeyEvent{Key: KeyPageDown}, ""},
+		{"\x1b[1~", KeyEvent{Key: KeyHome}, ""},
+		{"\x1b[4~", KeyEvent{Key: KeyEnd}, ""},
+		{"\x1bOP", KeyEvent{Key: KeyF1}, ""},
+		{"\x1b[[E", KeyEvent{Key: KeyF5}, ""},
+		{"\x1b[15~", KeyEvent{Key: KeyF5}, ""},
+		{"\x1b[24~", KeyEvent{Key: KeyF12}, ""},
+		{"\x1b[1;5C", KeyEvent{Key: KeyRight, Mod: ModCtrl}, ""},
+		{"\x1b[1;3D", KeyEvent{Key: KeyLeft, Mod: ModAlt}, ""},
+		{"\x1b[1;2A", KeyEvent{Key: KeyUp, Mod: ModShift}, ""},
+		{"\x1b[1;7P", KeyEvent{Key: KeyF1, Mod: ModCtrl | ModAlt}, ""},
+		{"\x1b[3;5~", KeyEvent{Key: KeyDelete, Mod: ModCtrl}, ""},
+		{"\x1b[Z", KeyEvent{Key: KeyTab, Mod: ModShift}, ""},
+		{"\x1bd", KeyEvent{Key: 'd', Mod: ModAlt}, ""},
+		{"\x1bz", KeyEvent{Key: 'z', Mod: ModAlt}, ""},
+		{"\x1bZx", KeyEvent{Key: 'Z', Mod: ModAlt}, "x"},
+		{"\x1b.", KeyEvent{Key: '.', Mod: ModAlt}, ""},
+		{"\x1b[1C", KeyEvent{Key: KeyRight}, ""},
+		{"\x1b[5C", KeyEvent{Key: KeyUnknown}, ""},
+		{"\x1b[2;5A", KeyEvent{Key: KeyUnknown}, ""},
+		{"\x1b[99~", KeyEvent{Key: KeyUnknown}, ""},
+		{"\x1b[?1;2c", KeyEvent{Key: KeyUnknown}, ""},
+	}
+
+	for i, test := range tests {
+		event, rest, ok := DecodeKey([]byte(test.in))
+		if !ok {
+			t.Errorf("test %d: %q was not decoded", i, test.in)
+			continue
+		}
+		if event != test.event || string(rest) != test.rest {
+			t.Errorf("test %d: %q was decoded to %v, %q, expected %v, %q", i, test.in, event, rest, test.event, test.rest)
+		}
+	}
+
+	for _, partial := range []string{"", "\x1b[", "\x1b[1;5", "\x1bO", "\x1b[["} {
+		if _, _, ok := DecodeKey([]byte(partial)); ok {
+			t.Errorf("partial sequence %q was decoded", partial)
+		}
+	}
+}
+
+func TestKeyEventString(t *testing.T) {
+	tests := []struct {
+		event    KeyEvent
+		expected string
+	}{
+		{KeyEvent{Key: KeyF1}, "F1"},
+		{KeyEvent{Key: KeyPageDown, Mod: ModShift}, "Shift-PageDown"},
+		{KeyEvent{Key: 'r', Mod: ModCtrl | ModAlt}, "Ctrl-Alt-r"},
+	}
+	for _, test := range tests {
+		if got := test.event.String(); got != test.expected {
+			t.Errorf("%#v.String() was %q, expected %q", test.event, got, test.expected)
+		}
+	}
+}
+
+func TestBindKey(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\x1bOP\x1b[24~\x05\x1b[1;5Pc\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+
+	var helped string
+	help := func(line string, pos int) (string, int) {
+		helped = line
+		return line, pos
+	}
+	if err := ss.BindKey(KeyEvent{Key: KeyF1}, help); err != nil {
+		t.Fatalf("BindKey returned %v", err)
+	}
+	ss.BindKey(KeyEvent{Key: KeyF12}, func(line string, pos int) (string, int) {
+		return "x" + line, 0
+	})
+	ss.BindKey(KeyEvent{Key: 'e', Mod: ModCtrl}, func(line string, pos int) (string, int) {
+		return line + "!", len(line) + 1
+	})
+	ss.BindKey(KeyEvent{Key: KeyF1, Mod: ModCtrl}, help)
+	ss.BindKey(KeyEvent{Key: KeyF1, Mod: ModCtrl}, nil)
+
+	line, err := ss.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine returned %v", err)
+	}
+	if helped != "ab" {
+		t.Errorf("F1 action got %q, expected \"ab\"", helped)
+	}
+	if line != "xab!c" {
+		t.Errorf("line was %q, expected \"xab!c\"", line)
+	}
+
+	if err := ss.BindKey(KeyEvent{Key: '[', Mod: ModAlt}, help); err != ErrKeyNotBindable {
+		t.Errorf("binding Alt-[ returned %v, expected ErrKeyNotBindable", err)
+	}
+}
+
+func TestBindAltKey(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\x1bzc\x1bq\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	if err := ss.BindKey(KeyEvent{Key: 'z', Mod: ModAlt}, func(line string, pos int) (string, int) {
+		return line + "!", len(line) + 1
+	}); err != nil {
+		t.Fatalf("BindKey returned %v", err)
+	}
+
+	// Alt-q is not bound and does nothing.
+	if line, err := ss.ReadLine(); line != "ab!c" || err != nil {
+		t.Errorf("ReadLine returned %q, %v, expected \"ab!c\"", line, err)
+	}
+}
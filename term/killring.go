@@ -0,0 +1,172 @@
+package term
+
+import "unicode"
+
+// maxKillRingEntries is the number of killed texts kept for yanking.
+const maxKillRingEntries = 30
+
+// Editor commands tracked in Terminal.lastCommand so that consecutive kills
+// are merged, Alt-Y only rotates directly after a yank and consecutive
+// inserts are undone together.
+const (
+	commandOther = iota
+	commandKill
+	commandYank
+	commandInsert
+	commandUndo
+)
+
+// kill saves text to the kill ring. Consecutive kills are merged into a
+// single entry; text killed backwards is prepended.
+func (t *Terminal) kill(text []rune, backward bool) {
+	if len(text) == 0 {
+		t.lastCommand = commandKill
+		return
+	}
+
+	if t.prevCommand == commandKill && len(t.killRing) > 0 {
+		top := len(t.killRing) - 1
+		if backward {
+			t.killRing[top] = append(append([]rune(nil), text...), t.killRing[top]...)
+		} else {
+			t.killRing[top] = append(t.killRing[top], text...)
+		}
+	} else {
+		t.killRing = append(t.killRing, append([]rune(nil), text...))
+		if len(t.killRing) > maxKillRingEntries {
+			t.killRing = t.killRing[1:]
+		}
+	}
+	t.lastCommand = commandKill
+}
+
+// killBackward kills n characters before the cursor.
+func (t *Terminal) killBackward(n int) {
+	if n > t.pos {
+		n = t.pos
+	}
+	t.kill(t.line[t.pos-n:t.pos], true)
+	t.eraseNPreviousChars(n)
+}
+
+// killForward kills n characters after the cursor.
+func (t *Terminal) killForward(n int) {
+	if t.pos+n > len(t.line) {
+		n = len(t.line) - t.pos
+	}
+	t.kill(t.line[t.pos:t.pos+n], false)
+	if n > 0 {
+		t.pos += n
+		t.eraseNPreviousChars(n)
+	}
+}
+
+// yank inserts the most recently killed text at the cursor.
+func (t *Terminal) yank() {
+	if len(t.killRing) == 0 {
+		return
+	}
+	t.yankIndex = len(t.killRing) - 1
+	t.insertYank(t.killRing[t.yankIndex])
+}
+
+// yankPop replaces the text inserted by the previous yank with the next
+// older entry of the kill ring.
+func (t *Terminal) yankPop() {
+	if t.prevCommand != commandYank || len(t.killRing) == 0 {
+		return
+	}
+
+	start := t.pos - t.yankLen
+	newLine := append([]rune(nil), t.line[:start]...)
+	newLine = append(newLine, t.line[t.pos:]...)
+	t.setLine(newLine, start)
+
+	t.yankIndex--
+	if t.yankIndex < 0 {
+		t.yankIndex = len(t.killRing) - 1
+	}
+	t.insertYank(t.killRing[t.yankIndex])
+}
+
+func (t *Terminal) insertYank(text []rune) {
+	t.insertRunes(text)
+	t.yankLen = len(text)
+	t.lastCommand = commandYank
+}
+
+// insertRunes inserts text at the cursor and moves the cursor after it.
+func (t *Terminal) insertRunes(text []rune) {
+	if len(t.line)+len(text) > maxLineLength {
+		return
+	}
+	newLine := make([]rune, 0, len(t.line)+len(text))
+	newLine = append(newLine, t.line[:t.pos]...)
+	newLine = append(newLine, text...)
+	newLine = append(newLine, t.line[t.pos:]...)
+	t.setLine(newLine, t.pos+len(text))
+}
+
+// countToRightWordEnd returns the number of characters from the cursor to
+// the end of the current or next word.
+func (t *Terminal) countToRightWordEnd() int {
+	pos := t.pos
+	for pos < len(t.line) && t.line[pos] == ' ' {
+		pos++
+	}
+	for pos < len(t.line) && t.line[pos] != ' ' {
+		pos++
+	}
+	return pos - t.pos
+}
+
+// transposeChars swaps the character before the cursor with the one under
+// it and moves the cursor forward. At the end of the line the last two
+// characters are swapped.
+func (t *Terminal) transposeChars() {
+	if t.pos == 0 || len(t.line) < 2 {
+		return
+	}
+	pos := t.pos
+	if pos == len(t.line) {
+		pos--
+	}
+	newLine := append([]rune(nil), t.line...)
+	newLine[pos-1], newLine[pos] = newLine[pos], newLine[pos-1]
+	t.setLine(newLine, pos+1)
+}
+
+// Case changes applied by changeWordCase.
+const (
+	caseUpper = iota
+	caseLower
+	caseCapitalize
+)
+
+// changeWordCase changes the case of the characters from the cursor to the
+// end of the word and moves the cursor after the word.
+func (t *Terminal) changeWordCase(change int) {
+	n := t.countToRightWordEnd()
+	if n == 0 {
+		return
+	}
+	newLine := append([]rune(nil), t.line...)
+	first := true
+	for i := t.pos; i < t.pos+n; i++ {
+		r := newLine[i]
+		switch {
+		case change == caseUpper:
+			r = unicode.ToUpper(r)
+		case change == caseLower:
+			r = unicode.ToLower(r)
+		case r == ' ':
+		case first:
+			r = unicode.ToUpper(r)
+			first = false
+		default:
+			r = unicode.ToLower(r)
+		}
+		newLine[i] = r
+	}
+	t.setLine(newLine, t.pos+n)
+}
@@ -0,0 +1,180 @@
+package term
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MouseButton is the button of a mouse event.
+type MouseButton int
+
+// Mouse buttons. MouseNone is reported for motion without a pressed button.
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	MouseNone
+	MouseWheelUp
+	MouseWheelDown
+)
+
+// MouseEvent is a mouse event reported by the terminal.
+type MouseEvent struct {
+	Button MouseButton
+	// X and Y are the column and row of the event on the screen, starting
+	// with 0 at the top left corner.
+	X, Y int
+	// Pressed is true when a button is pressed and false when it is
+	// released.
+	Pressed bool
+	// Motion is true if the mouse was moved while a button was held down.
+	Motion bool
+	Mod    KeyMod
+}
+
+// SetMouse turns mouse reporting on or off. While it is on, the terminal
+// reports clicks using SGR mouse mode and the terminal's own text selection
+// usually requires holding Shift. Mouse reporting should be turned off before
+// the terminal is handed back to the shell.
+func (t *Terminal) SetMouse(on bool) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	seq := "\x1b[?1000h\x1b[?1002h\x1b[?1006h"
+	if !on {
+		seq = "\x1b[?1006l\x1b[?1002l\x1b[?1000l"
+		t.click = nil
+	}
+	_, err := t.c.Write([]byte(seq))
+	return err
+}
+
+// parseMouse parses an SGR mouse sequence, ESC [ < button ; x ; y M or m.
+func parseMouse(seq []byte) (MouseEvent, bool) {
+	if len(seq) < 4 || seq[2] != '<' {
+		return MouseEvent{}, false
+	}
+	args := strings.Split(string(seq[3:len(seq)-1]), ";")
+	if len(args) != 3 {
+		return MouseEvent{}, false
+	}
+	var n [3]int
+	for i, arg := range args {
+		var err error
+		if n[i], err = strconv.Atoi(arg); err != nil {
+			return MouseEvent{}, false
+		}
+	}
+
+	code := n[0]
+	event := MouseEvent{
+		X:       n[1] - 1,
+		Y:       n[2] - 1,
+		Pressed: seq[len(seq)-1] == 'M',
+		Motion:  code&32 != 0,
+	}
+	if code&4 != 0 {
+		event.Mod |= ModShift
+	}
+	if code&8 != 0 {
+		event.Mod |= ModAlt
+	}
+	if code&16 != 0 {
+		event.Mod |= ModCtrl
+	}
+	switch button := code & 3; {
+	case code&64 != 0 && button == 0:
+		event.Button = MouseWheelUp
+	case code&64 != 0:
+		event.Button = MouseWheelDown
+	default:
+		event.Button = MouseButton(button)
+	}
+	return event, true
+}
+
+// parseCursorReport parses a cursor position report, ESC [ row ; column R,
+// and returns the position starting with 0.
+func parseCursorReport(seq []byte) (x, y int, ok bool) {
+	if len(seq) < 6 || seq[len(seq)-1] != 'R' {
+		return 0, 0, false
+	}
+	args := strings.Split(string(seq[2:len(seq)-1]), ";")
+	if len(args) != 2 {
+		return 0, 0, false
+	}
+	row, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	col, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return col - 1, row - 1, true
+}
+
+// handleReport processes the mouse events and cursor position reports sent
+// by the terminal. seq is the input the key was decoded from. It returns
+// false if the key is an ordinary key press.
+func (t *Terminal) handleReport(key rune, seq []byte) bool {
+	if t.click != nil {
+		if _, y, ok := parseCursorReport(seq); ok {
+			click := t.click
+			t.click = nil
+			t.moveToClick(click.X, click.Y-(y-t.cursorY))
+			return true
+		}
+	}
+	if key != keyMouse {
+		return false
+	}
+
+	event, ok := parseMouse(seq)
+	if !ok {
+		return true
+	}
+	if t.MouseCallback != nil {
+		t.lock.Unlock()
+		handled := t.MouseCallback(event)
+		t.lock.Lock()
+
+		if handled {
+			return true
+		}
+	}
+
+	if event.Button == MouseLeft && event.Pressed && !event.Motion && t.echo && t.search == nil && t.menu == nil {
+		// The row of the click in the input line is only known once
+		// the terminal reported where the cursor is.
+		t.click = &event
+		t.queue([]rune("\x1b[6n"))
+	}
+	return true
+}
+
+// moveToClick moves the cursor to the position shown at x, y relative to the
+// start of the prompt. Clicks outside of the input are ignored.
+func (t *Terminal) moveToClick(x, y int) {
+	if _, endY := t.screenPos(len(t.line)); y < 0 || y > endY {
+		return
+	}
+
+	pos := 0
+	for i := 0; ; i += t.graphemeAfter(i) {
+		px, py := t.screenPos(i)
+		if py > y || (py == y && px > x) {
+			break
+		}
+		pos = i
+		if i == len(t.line) {
+			break
+		}
+	}
+	if pos == t.pos {
+		return
+	}
+	t.pos = pos
+	t.moveCursorToPos(t.pos)
+	t.updateHint()
+}
@@ -0,0 +1,103 @@
+package term
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMouse(t *testing.T) {
+	tests := []struct {
+		in    string
+		event MouseEvent
+	}{
+		{"\x1b[<0;5;3M", MouseEvent{Button: MouseLeft, X: 4, Y: 2, Pressed: true}},
+		{"\x1b[<0;5;3m", MouseEvent{Button: MouseLeft, X: 4, Y: 2}},
+		{"\x1b[<2;1;1M", MouseEvent{Button: MouseRight, Pressed: true}},
+		{"\x1b[<20;1;1M", MouseEvent{Button: MouseLeft, Pressed: true, Mod: ModShift | ModCtrl}},
+		{"\x1b[<32;10;2M", MouseEvent{Button: MouseLeft, X: 9, Y: 1, Pressed: true, Motion: true}},
+		{"\x1b[<64;1;1M", MouseEvent{Button: MouseWheelUp, Pressed: true}},
+		{"\x1b[<65;1;1M", MouseEvent{Button: MouseWheelDown, Pressed: true}},
+	}
+	for i, test := range tests {
+		key, rest := bytesToKey([]byte(test.in), false)
+		if key != keyMouse || len(rest) != 0 {
+			t.Errorf("test %d: %q was decoded to %x, %q", i, test.in, key, rest)
+		}
+		event, ok := parseMouse([]byte(test.in))
+		if !ok || event != test.event {
+			t.Errorf("test %d: %q was parsed to %+v, expected %+v", i, test.in, event, test.event)
+		}
+	}
+}
+
+func TestMouseClick(t *testing.T) {
+	tests := []struct {
+		in   string
+		line string
+	}{
+		// A click on the input line moves the cursor there once the
+		// cursor position is reported.
+		{"hello world\x1b[<0;5;5M\x1b[5;14RX\r", "heXllo world"},
+		// A click on the prompt moves to the start of the line.
+		{"hello\x1b[<0;1;5M\x1b[5;8RX\r", "Xhello"},
+		// A click after the end of the line moves to its end.
+		{"hello\x1b[D\x1b[D\x1b[<0;40;5M\x1b[5;6RX\r", "helloX"},
+		// Clicks above or below the input line are ignored.
+		{"hello\x1b[<0;3;2M\x1b[5;8RX\r", "helloX"},
+		{"hello\x1b[<0;3;6M\x1b[5;8RX\r", "helloX"},
+		// Releases and other buttons don't move the cursor.
+		{"hello\x1b[<0;3;5m\x1b[<2;3;5MX\r", "helloX"},
+		// Clicks on a wrapped line. The line wraps after 18
+		// characters in a 20 column terminal.
+		{"abcdefghijklmnopqrstuvwxyz\x1b[<0;3;6M\x1b[6;9RX\r", "abcdefghijklmnopqrstXuvwxyz"},
+	}
+
+	for i, test := range tests {
+		for j := 1; j < len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			ss.SetSize(20, 24)
+			line, err := ss.ReadLine()
+			if err != nil {
+				t.Fatalf("test %d (%d bytes per read): ReadLine returned %v", i, j, err)
+			}
+			if line != test.line {
+				t.Errorf("test %d (%d bytes per read): line was %q, expected %q", i, j, line, test.line)
+				break
+			}
+		}
+	}
+}
+
+func TestMouseCallback(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\x1b[<0;1;1M\x1b[<65;2;3Mc\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	var events []MouseEvent
+	ss.MouseCallback = func(event MouseEvent) bool {
+		events = append(events, event)
+		return true
+	}
+	if err := ss.SetMouse(true); err != nil {
+		t.Fatalf("SetMouse returned %v", err)
+	}
+	line, err := ss.ReadLine()
+	if err != nil || line != "abc" {
+		t.Fatalf("ReadLine returned %q, %v, expected \"abc\"", line, err)
+	}
+	if len(events) != 2 || events[1].Button != MouseWheelDown || events[1].X != 1 || events[1].Y != 2 {
+		t.Errorf("incorrect mouse events: %+v", events)
+	}
+	out := string(c.received)
+	if !strings.HasPrefix(out, "\x1b[?1000h\x1b[?1002h\x1b[?1006h") {
+		t.Errorf("mouse reporting was not turned on: %q", out)
+	}
+	if strings.Contains(out, "\x1b[6n") {
+		t.Errorf("cursor position was requested for a handled click: %q", out)
+	}
+}
@@ -0,0 +1,117 @@
+package term
+
+// A multi-line input is kept in Terminal.line with the lines separated by
+// '\n'. Each line after the first is written after the continuation prompt
+// and starts on a new row of the screen.
+
+// isMultiLine returns true if line contains more than one line of input.
+func isMultiLine(line []rune) bool {
+	return indexRune(line, '\n') >= 0
+}
+
+// indexRune returns the index of the first r in line, or -1.
+func indexRune(line []rune, r rune) int {
+	for i, c := range line {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// screenPos returns the position on the screen, relative to the start of the
+// prompt, of the logical position pos in the line.
+func (t *Terminal) screenPos(pos int) (x, y int) {
+	x, y = t.layout(0, 0, t.displayPrompt())
+	line := t.line
+	if pos < len(line) {
+		line = line[:pos]
+	}
+
+	start := 0
+	for i, r := range line {
+		if r != '\n' {
+			continue
+		}
+		x, y = t.layout(x, y, line[start:i])
+		x, y = t.layout(0, y+1, t.continuationPrompt)
+		start = i + 1
+	}
+	return t.layout(x, y, line[start:])
+}
+
+// writeNewline ends the current row of a multi-line input and writes the
+// continuation prompt on the next one.
+func (t *Terminal) writeNewline() {
+	t.clearLineToRight()
+	t.queue([]rune("\r\n"))
+	t.cursorX = 0
+	t.cursorY++
+	if t.cursorY > t.maxLine {
+		t.maxLine = t.cursorY
+	}
+	t.queue(t.continuationPrompt)
+	t.advanceCursor(visualLength(t.continuationPrompt))
+}
+
+// repaint redraws the prompt and the whole input.
+func (t *Terminal) repaint() {
+	if !t.echo {
+		return
+	}
+	t.clearAndRepaintLinePlusNPrevious(t.maxLine)
+}
+
+// lineStart returns the position of the start of the line of input that
+// contains pos.
+func (t *Terminal) lineStart(pos int) int {
+	for pos > 0 && t.line[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+// lineEnd returns the position of the end of the line of input that contains
+// pos.
+func (t *Terminal) lineEnd(pos int) int {
+	for pos < len(t.line) && t.line[pos] != '\n' {
+		pos++
+	}
+	return pos
+}
+
+// moveToPreviousLine moves the cursor to the same column of the previous line
+// of a multi-line input. It returns false if the cursor is on the first line.
+func (t *Terminal) moveToPreviousLine() bool {
+	start := t.lineStart(t.pos)
+	if start == 0 {
+		return false
+	}
+	prevStart := t.lineStart(start - 1)
+	t.pos = prevStart + min(t.pos-start, start-1-prevStart)
+	t.moveCursorToPos(t.pos)
+	return true
+}
+
+// moveToNextLine moves the cursor to the same column of the next line of a
+// multi-line input. It returns false if the cursor is on the last line.
+func (t *Terminal) moveToNextLine() bool {
+	end := t.lineEnd(t.pos)
+	if end == len(t.line) {
+		return false
+	}
+	nextStart := end + 1
+	t.pos = nextStart + min(t.pos-t.lineStart(t.pos), t.lineEnd(nextStart)-nextStart)
+	t.moveCursorToPos(t.pos)
+	return true
+}
+
+// killToLineEnd kills the text from the cursor to end, the end of a line
+// that is not the last. At the end of the line the newline is killed,
+// joining the next line.
+func (t *Terminal) killToLineEnd(end int) {
+	if end == t.pos {
+		end++
+	}
+	t.killForward(end - t.pos)
+}
@@ -0,0 +1,299 @@
+package term
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Printf formats according to a format specifier and writes the result above
+// the prompt, like Write, ending it with a newline if it has none. It can be
+// called from any goroutine, including while ReadLine is in progress, and is
+// not paged.
+func (t *Terminal) Printf(format string, a ...interface{}) (n int, err error) {
+	text := fmt.Sprintf(format, a...)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return t.write([]byte(text))
+}
+
+// Progress is a line shown above the prompt that reports the progress of a
+// background task. Output written to the terminal scrolls above it.
+type Progress struct {
+	t              *Terminal
+	label          string
+	current, total int
+
+	// frame is the frame of a spinner that is shown, and stop ends its
+	// animation. stop is nil for a progress bar.
+	frame int
+	stop  chan struct{}
+}
+
+// spinnerFrames are the frames a spinner cycles through.
+var spinnerFrames = []rune{'|', '/', '-', '\\'}
+
+// spinnerInterval is the time between frames of a spinner.
+const spinnerInterval = 100 * time.Millisecond
+
+// StartProgress shows a progress line with label above the prompt. It stays
+// until Done is called. Other output written while progress lines are shown
+// always ends its line.
+func (t *Terminal) StartProgress(label string) *Progress {
+	p := &Progress{t: t, label: label}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.progress = append(t.progress, p)
+	t.writeAbove(nil)
+	return p
+}
+
+// StartSpinner shows an animated spinner with label above the prompt, for
+// tasks whose progress is not known. Like a progress line, it stays until Done
+// is called. Update adds a count to it.
+func (t *Terminal) StartSpinner(label string) *Progress {
+	p := &Progress{t: t, label: label, stop: make(chan struct{})}
+
+	t.lock.Lock()
+	t.progress = append(t.progress, p)
+	t.writeAbove(nil)
+	t.lock.Unlock()
+
+	go p.spin(p.stop)
+	return p
+}
+
+// spin advances the spinner until stop is closed by Done.
+func (p *Progress) spin(stop <-chan struct{}) {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		t := p.t
+		t.lock.Lock()
+		p.frame++
+		t.redrawProgress(p)
+		t.lock.Unlock()
+	}
+}
+
+// Update sets the progress to current out of total and redraws the line. If
+// total is not positive, only current is shown.
+func (p *Progress) Update(current, total int) {
+	t := p.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p.current, p.total = current, total
+	t.writeAbove(nil)
+}
+
+// Done removes the progress line. If message is not empty it is written in
+// its place.
+func (p *Progress) Done(message string) {
+	t := p.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	for i, q := range t.progress {
+		if q == p {
+			t.progress = append(t.progress[:i:i], t.progress[i+1:]...)
+			break
+		}
+	}
+	var buf []byte
+	if message != "" {
+		buf = []byte(strings.TrimSuffix(message, "\n") + "\n")
+	}
+	t.writeAbove(buf)
+}
+
+// render returns the progress line, fitting in width columns.
+func (p *Progress) render(width int) []rune {
+	text := p.label + " " + strconv.Itoa(p.current)
+	if p.stop != nil {
+		text = string(spinnerFrames[p.frame%len(spinnerFrames)]) + " " + p.label
+		if p.total > 0 {
+			text += " " + strconv.Itoa(p.current) + "/" + strconv.Itoa(p.total)
+		} else if p.current > 0 {
+			text += " " + strconv.Itoa(p.current)
+		}
+	} else if p.total > 0 {
+		current := min(max(p.current, 0), p.total)
+		percent := " " + strconv.Itoa(current*100/p.total) + "%"
+		bar := width - visualLength([]rune(p.label)) - len(percent) - 3
+		if bar < 10 {
+			text = p.label + percent
+		} else {
+			filled := bar * current / p.total
+			text = p.label + " [" + strings.Repeat("=", filled) + strings.Repeat(" ", bar-filled) + "]" + percent
+		}
+	}
+
+	line := []rune(text)
+	n, _ := fitGlyphs(line, width)
+	return line[:n]
+}
+
+// writeProgress writes the progress lines, each on a row of its own. The
+// cursor must be at the start of a row.
+func (t *Terminal) writeProgress() {
+	for _, p := range t.progress {
+		t.queue(p.render(t.termWidth - 1))
+		t.outBuf = append(t.outBuf, crlf...)
+	}
+	t.progressRows = len(t.progress)
+}
+
+// redrawProgress redraws the row of p in place, leaving the prompt, the other
+// progress lines and any widget as they are. t.lock must be held.
+func (t *Terminal) redrawProgress(p *Progress) {
+	if t.screen != nil {
+		return
+	}
+	row := -1
+	for i, q := range t.progress {
+		if q == p {
+			row = i
+		}
+	}
+	if row < 0 {
+		return
+	}
+	if t.progressRows != len(t.progress) {
+		t.writeAbove(nil)
+		return
+	}
+
+	// The progress lines are right above the first row of the prompt or
+	// the widget.
+	t.queue([]rune("\x1b7"))
+	t.move(t.cursorY+t.progressRows-row, 0, 0, 0)
+	t.queue([]rune("\r"))
+	t.queue(p.render(t.termWidth - 1))
+	t.queue([]rune("\x1b[K\x1b8"))
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+}
+
+// clearProgress erases the progress lines above the cursor, which must be at
+// the start of the row below them.
+func (t *Terminal) clearProgress() {
+	if t.progressRows == 0 {
+		return
+	}
+	t.move(t.progressRows, 0, 0, 0)
+	t.queue([]rune{keyEscape, '[', 'J'})
+	t.progressRows = 0
+	t.queueStatus()
+}
+
+// moveProgressBelow is called after a line has been entered, with the cursor
+// at the start of the row below it. The line is written again above the
+// progress lines, so that they stay right above the next prompt.
+func (t *Terminal) moveProgressBelow() {
+	if t.progressRows == 0 {
+		return
+	}
+	t.move(t.cursorY+1, 0, 0, 0)
+	t.cursorX, t.cursorY = 0, 0
+	t.clearProgress()
+	t.writeLine(t.displayPrompt())
+	if t.echo {
+		t.writeInput(0)
+	}
+	t.moveCursorToPos(len(t.line))
+	t.outBuf = append(t.outBuf, crlf...)
+	t.writeProgress()
+}
+
+// SetStatus shows text on the bottom row of the screen. The row is taken out
+// of the scrolling region of the terminal, so the status line stays in place
+// while output scrolls and is redrawn after the screen is cleared or resized.
+// An empty text removes the status line, which should be done before the
+// terminal is handed back to the shell.
+func (t *Terminal) SetStatus(text string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.screen != nil {
+		// The status line is drawn once the screen is closed.
+		t.status = []rune(text)
+		return
+	}
+	if text == "" {
+		if len(t.status) == 0 {
+			return
+		}
+		t.status = nil
+		t.queueClearStatus()
+	} else {
+		if len(t.status) == 0 {
+			t.reserveStatusRow()
+		}
+		t.status = []rune(text)
+		t.queueStatus()
+	}
+
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+}
+
+// queueClearStatus resets the scrolling region and clears the bottom row.
+func (t *Terminal) queueClearStatus() {
+	t.queue([]rune("\x1b7\x1b[r\x1b[" + strconv.Itoa(t.termHeight) + ";1H\x1b[2K\x1b8"))
+}
+
+// reserveStatusRow makes sure that the row below the input is not the bottom
+// row of the screen, scrolling up if necessary, so that the bottom row can be
+// taken out of the scrolling region.
+func (t *Terminal) reserveStatusRow() {
+	t.moveCursorToPos(len(t.line))
+	t.queue([]rune("\n\x1b[A"))
+	t.moveCursorToPos(t.pos)
+}
+
+// queueStatus sets the scrolling region to all but the bottom row and writes
+// the status line there, leaving the cursor where it is.
+func (t *Terminal) queueStatus() {
+	if len(t.status) == 0 || t.termHeight < 2 {
+		return
+	}
+	n, _ := fitGlyphs(t.status, t.termWidth-1)
+	height := strconv.Itoa(t.termHeight)
+	t.queue([]rune("\x1b7\x1b[1;" + strconv.Itoa(t.termHeight-1) + "r\x1b[" + height + ";1H\x1b[2K"))
+	t.queue(t.status[:n])
+	t.queue([]rune("\x1b8"))
+}
+
+// outputHeight returns the number of rows available for output, which
+// excludes the status line.
+func (t *Terminal) outputHeight() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.screenHeight()
+}
+
+// screenHeight returns the number of rows above the status line. t.lock must
+// be held.
+func (t *Terminal) screenHeight() int {
+	if len(t.status) > 0 {
+		return t.termHeight - 1
+	}
+	return t.termHeight
+}
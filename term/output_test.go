@@ -0,0 +1,108 @@
+package term
+
+import (
+	"io"
+	"testing"
+)
+
+// editingTerminal returns a terminal that has read "ab" into the line being
+// edited, as if ReadLine were still waiting for input.
+func editingTerminal() (*MockTerminal, *Terminal) {
+	c := &MockTerminal{
+		toSend:       []byte("ab"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	if _, err := ss.ReadLine(); err != io.EOF {
+		panic(err)
+	}
+	c.received = nil
+	return c, ss
+}
+
+func TestPrintf(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ")
+	ss.Printf("job %d done", 1)
+	if got, expected := string(c.received), "job 1 done\r\n"; got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+
+	c, ss = editingTerminal()
+	ss.Printf("job %d done\n", 2)
+	if got, expected := string(c.received), "\x1b[4D\x1b[Kjob 2 done\r\n> ab"; got != expected {
+		t.Errorf("incorrect output while editing: was %q, expected %q", got, expected)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	c, ss := editingTerminal()
+	ss.SetSize(30, 24)
+	c.received = nil
+
+	p := ss.StartProgress("copy")
+	expected := "\x1b[4D\x1b[K" + "copy 0\r\n" + "> ab"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on start: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	p.Update(5, 10)
+	expected = "\x1b[4D\x1b[K\x1b[A\x1b[J" + "copy [=========         ] 50%\r\n" + "> ab"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on update: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	ss.Printf("log")
+	expected = "\x1b[4D\x1b[K\x1b[A\x1b[J" + "log\r\n" + "copy [=========         ] 50%\r\n" + "> ab"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output above progress: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	p.Done("copied")
+	expected = "\x1b[4D\x1b[K\x1b[A\x1b[J" + "copied\r\n" + "> ab"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on done: was %q, expected %q", got, expected)
+	}
+}
+
+func TestProgressStaysAbovePrompt(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ls\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.StartProgress("copy")
+	c.received = nil
+	ss.ReadLine()
+	// The entered line is written again above the progress line.
+	expected := "> ls" + "\r\n" + "\x1b[A\x1b[A\x1b[J" + "> ls\r\n" + "copy 0\r\n"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+}
+
+func TestStatusLine(t *testing.T) {
+	c, ss := editingTerminal()
+	ss.SetStatus("connected")
+	expected := "\n\x1b[A" + "\x1b7\x1b[1;23r\x1b[24;1H\x1b[2Kconnected\x1b8"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on set: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	ss.SetSize(80, 30)
+	expected = "\n\x1b[A" + "\x1b7\x1b[1;29r\x1b[30;1H\x1b[2Kconnected\x1b8"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on resize: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	ss.SetStatus("")
+	expected = "\x1b7\x1b[r\x1b[30;1H\x1b[2K\x1b8"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on remove: was %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,263 @@
+package term
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// pager pages output written to a Terminal. Once a screen full of lines has
+// been written it shows a --More-- prompt and waits for a key:
+//
+//	space    show the next page
+//	enter    show the next line
+//	q        discard the rest of the output
+//	/text    skip forward to the next line containing text
+type pager struct {
+	t    *Terminal
+	lock sync.Mutex
+
+	// lines is the number of rows written on the current page and col the
+	// column of the cursor on the current row.
+	lines, col int
+	// escape finds the escape sequences in the output.
+	escape escapeScanner
+	// quit is true once the user has asked to discard the output.
+	quit bool
+	// search is the pattern being searched for. While it is set, output is
+	// buffered in searchLine and discarded until a matching line is found.
+	search     string
+	searchLine []rune
+}
+
+// SetPaging enables or disables paging of output for the session. Paging is
+// enabled by default but only takes effect between StartPaging and
+// StopPaging.
+func (t *Terminal) SetPaging(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.pagingDisabled = !on
+}
+
+// Paging returns true if paging of output is enabled for the session.
+func (t *Terminal) Paging() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return !t.pagingDisabled
+}
+
+// StartPaging pages everything written to the terminal until StopPaging is
+// called. When the output is taller than the terminal a --More-- prompt is
+// shown. It must not be called while ReadLine is in progress.
+func (t *Terminal) StartPaging() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.pagingDisabled || t.termHeight <= 1 {
+		return
+	}
+	t.pager = &pager{t: t}
+}
+
+// StopPaging stops paging output started with StartPaging. If a search is
+// still pending, a partial last line matching it is written, otherwise the
+// pattern is reported as not found.
+func (t *Terminal) StopPaging() {
+	t.lock.Lock()
+	p := t.pager
+	t.pager = nil
+	t.lock.Unlock()
+
+	if p != nil {
+		p.stop()
+	}
+}
+
+func (t *Terminal) activePager() *pager {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.pager
+}
+
+func (p *pager) Write(buf []byte) (n int, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	n = len(buf)
+	width, _ := p.t.Size()
+	pageSize := p.t.outputHeight() - 1
+
+	var out []byte
+	flush := func() error {
+		if len(out) == 0 {
+			return nil
+		}
+		_, err := p.t.write(out)
+		out = out[:0]
+		return err
+	}
+
+	for len(buf) > 0 && !p.quit {
+		r, l := utf8.DecodeRune(buf)
+		chunk := buf[:l]
+		buf = buf[l:]
+
+		if p.search != "" {
+			p.searchFor(r)
+			continue
+		}
+
+		switch {
+		case p.escape.scan(r):
+			out = append(out, chunk...)
+			continue
+		case r == '\r':
+			p.col = 0
+			out = append(out, chunk...)
+			continue
+		}
+
+		w := 0
+		if isPrintable(r) {
+			w = runeWidth(r)
+		}
+		if p.col+w > width {
+			p.lines++
+			p.col = 0
+		}
+		if p.col == 0 && p.lines >= pageSize {
+			if err = flush(); err != nil {
+				return 0, err
+			}
+			if err = p.more(); err != nil {
+				return 0, err
+			}
+			if p.quit {
+				break
+			}
+			if p.search != "" {
+				p.searchFor(r)
+				continue
+			}
+		}
+
+		if r == '\n' {
+			p.lines++
+			p.col = 0
+		} else {
+			p.col += w
+		}
+		out = append(out, chunk...)
+	}
+
+	// Discarded output is reported as written so commands keep running
+	// normally after the user quits the pager.
+	if err = flush(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// searchFor buffers r into the current line and, once the line is complete,
+// checks it for the search pattern.
+func (p *pager) searchFor(r rune) {
+	if r != '\n' {
+		p.searchLine = append(p.searchLine, r)
+		return
+	}
+
+	line := string(p.searchLine)
+	p.searchLine = p.searchLine[:0]
+	if !strings.Contains(stripEscapes(line), p.search) {
+		return
+	}
+
+	p.search = ""
+	p.t.write([]byte("...skipping\n" + line + "\n"))
+	p.lines, p.col = 1, 0
+}
+
+// stop ends a pending search once all output has been written.
+func (p *pager) stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.search == "" || p.quit {
+		return
+	}
+	line := string(p.searchLine)
+	if strings.Contains(stripEscapes(line), p.search) {
+		p.t.write([]byte("...skipping\n" + line))
+	} else {
+		p.t.write([]byte("Pattern not found\n" + line))
+	}
+	p.search, p.searchLine = "", nil
+}
+
+// more shows the --More-- prompt and handles the key pressed by the user.
+func (p *pager) more() error {
+	prompt := "--More--"
+	if len(p.t.Escape.Reset) > 0 {
+		prompt = "\x1b[7m" + prompt + string(p.t.Escape.Reset)
+	}
+
+	for {
+		if _, err := p.t.write([]byte(prompt)); err != nil {
+			return err
+		}
+
+		key, err := p.t.readKey()
+		if err != nil {
+			p.quit = true
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if _, err := p.t.write([]byte("\r\x1b[K")); err != nil {
+			return err
+		}
+
+		switch key {
+		case ' ':
+			p.lines = 0
+			return nil
+		case keyEnter, '\n', keyDown:
+			p.lines--
+			return nil
+		case 'q', 'Q', keyCtrlC, keyCtrlD:
+			p.quit = true
+			return nil
+		case '/':
+			pattern, err := p.t.Prompt("/")
+			if err != nil {
+				p.quit = true
+				return nil
+			}
+			// Remove the search prompt line.
+			p.t.write([]byte("\x1b[A\r\x1b[K"))
+			if pattern == "" {
+				continue
+			}
+			p.search = pattern
+			return nil
+		}
+	}
+}
+
+// stripEscapes removes escape sequences from s.
+func stripEscapes(s string) string {
+	var b strings.Builder
+	var escape escapeScanner
+	for _, r := range s {
+		if !escape.scan(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
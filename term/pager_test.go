@@ -0,0 +1,128 @@
+package term
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func writeLines(t *Terminal, n int) {
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(t, "line%d\n", i)
+	}
+}
+
+var pagerTests = []struct {
+	keys     string
+	lines    int
+	received string
+}{
+	{
+		// Output fits on the screen, no prompt.
+		lines:    3,
+		received: "line0\r\nline1\r\nline2\r\n",
+	},
+	{
+		// Space shows the next page.
+		keys:  " ",
+		lines: 6,
+		received: "line0\r\nline1\r\nline2\r\nline3\r\n" +
+			"\x1b[7m--More--\x1b[0m\r\x1b[K" +
+			"line4\r\nline5\r\n",
+	},
+	{
+		// Enter shows one more line.
+		keys:  "\r\r",
+		lines: 6,
+		received: "line0\r\nline1\r\nline2\r\nline3\r\n" +
+			"\x1b[7m--More--\x1b[0m\r\x1b[K" +
+			"line4\r\n" +
+			"\x1b[7m--More--\x1b[0m\r\x1b[K" +
+			"line5\r\n",
+	},
+	{
+		// q discards the rest of the output.
+		keys:  "q",
+		lines: 10,
+		received: "line0\r\nline1\r\nline2\r\nline3\r\n" +
+			"\x1b[7m--More--\x1b[0m\r\x1b[K",
+	},
+	{
+		// Search skips forward to the matching line.
+		keys:  "/line8\r",
+		lines: 10,
+		received: "line0\r\nline1\r\nline2\r\nline3\r\n" +
+			"\x1b[7m--More--\x1b[0m\r\x1b[K" +
+			"/line8\r\n\x1b[A\r\x1b[K" +
+			"...skipping\r\nline8\r\nline9\r\n",
+	},
+}
+
+func TestPager(t *testing.T) {
+	for i, test := range pagerTests {
+		c := &MockTerminal{toSend: []byte(test.keys)}
+		ss := NewTerminal(c, "> ")
+		ss.SetSize(80, 5)
+		ss.StartPaging()
+		writeLines(ss, test.lines)
+		ss.StopPaging()
+
+		if string(c.received) != test.received {
+			t.Errorf("test %d: received %q, expected %q", i, c.received, test.received)
+		}
+	}
+}
+
+func TestPagerDisabled(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ")
+	ss.SetSize(80, 5)
+	ss.SetPaging(false)
+	ss.StartPaging()
+	writeLines(ss, 10)
+	ss.StopPaging()
+
+	if strings.Contains(string(c.received), "--More--") {
+		t.Errorf("output should not be paged when paging is disabled: %q", c.received)
+	}
+}
+
+func TestPagerWrappedLines(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("q")}
+	ss := NewTerminal(c, "> ")
+	ss.SetSize(10, 3)
+	ss.StartPaging()
+	// Each line wraps onto two rows, so only the first fits on a page.
+	fmt.Fprintf(ss, "%s\n%s\n", strings.Repeat("a", 15), strings.Repeat("b", 15))
+	ss.StopPaging()
+
+	if string(c.received) != strings.Repeat("a", 15)+"\r\n\x1b[7m--More--\x1b[0m\r\x1b[K" {
+		t.Errorf("received %q", c.received)
+	}
+}
+
+func TestPagerSearchPendingAtStop(t *testing.T) {
+	tests := []struct {
+		keys     string
+		received string
+	}{
+		{keys: "/tail\r", received: "...skipping\r\ntail"},
+		{keys: "/line99\r", received: "Pattern not found\r\ntail"},
+	}
+	for _, test := range tests {
+		c := &MockTerminal{toSend: []byte(test.keys)}
+		ss := NewTerminal(c, "> ")
+		ss.SetSize(80, 5)
+		ss.StartPaging()
+		writeLines(ss, 10)
+		fmt.Fprint(ss, "tail")
+		ss.StopPaging()
+
+		expected := "line0\r\nline1\r\nline2\r\nline3\r\n" +
+			"\x1b[7m--More--\x1b[0m\r\x1b[K" +
+			test.keys[:len(test.keys)-1] + "\r\n\x1b[A\r\x1b[K" + test.received
+		if string(c.received) != expected {
+			t.Errorf("%q: received %q, expected %q", test.keys, c.received, expected)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package term
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PasswordOptions control how a password is read.
+type PasswordOptions struct {
+	// Mask, if not zero, is shown for each character typed, so that the
+	// user sees the keystrokes register without the password showing.
+	Mask rune
+	// Context, if not nil, ends the prompt with its error once it is done,
+	// such as when its deadline passes.
+	Context context.Context
+	// Timeout, if positive, limits the time the user has to enter the
+	// password. The prompt then ends with context.DeadlineExceeded.
+	Timeout time.Duration
+}
+
+// context returns the context that ends the prompt, or nil if the prompt
+// waits forever.
+func (o PasswordOptions) context() (context.Context, context.CancelFunc) {
+	ctx := o.Context
+	if ctx == nil {
+		if o.Timeout <= 0 {
+			return nil, func() {}
+		}
+		ctx = context.Background()
+	}
+	if o.Timeout > 0 {
+		return context.WithTimeout(ctx, o.Timeout)
+	}
+	if ctx.Done() == nil {
+		return nil, func() {}
+	}
+	return ctx, func() {}
+}
+
+// wipe overwrites a buffer that held a password with zeros.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// appendSecret appends c to a password, wiping the old buffer if it has to
+// be moved to grow.
+func appendSecret(secret []byte, c ...byte) []byte {
+	if len(secret)+len(c) <= cap(secret) {
+		return append(secret, c...)
+	}
+	grown := make([]byte, len(secret), 2*cap(secret)+len(c)+16)
+	copy(grown, secret)
+	wipe(secret[:cap(secret)])
+	return append(grown, c...)
+}
+
+// readPasswordKeys reads a password typed a key at a time, with the terminal
+// in non-canonical mode. If mask is not zero, it is written to w for each
+// character. Backspace erases a character and Ctrl-U the whole password. A
+// read that returns no input gives ctx, if not nil, the chance to end the
+// prompt.
+func readPasswordKeys(ctx context.Context, r io.Reader, w io.Writer, mask rune) ([]byte, error) {
+	var buf [1]byte
+	var ret []byte
+	var erase string
+	if mask != 0 {
+		erase = strings.Repeat("\b \b", runeWidth(mask))
+	}
+
+	for {
+		if ctx != nil {
+			if err := ctx.Err(); err != nil {
+				wipe(ret)
+				return nil, err
+			}
+		}
+
+		n, err := r.Read(buf[:])
+		if n > 0 {
+			switch c := buf[0]; {
+			case c == '\r' || c == '\n':
+				return ret, nil
+			case c == '\b' || c == keyBackspace:
+				if len(ret) > 0 {
+					_, size := utf8.DecodeLastRune(ret)
+					wipe(ret[len(ret)-size:])
+					ret = ret[:len(ret)-size]
+					io.WriteString(w, erase)
+				}
+			case c == keyCtrlU:
+				io.WriteString(w, strings.Repeat(erase, utf8.RuneCount(ret)))
+				wipe(ret)
+				ret = ret[:0]
+			case c == keyCtrlD && len(ret) == 0:
+				return nil, io.EOF
+			case c < ' ':
+			default:
+				ret = appendSecret(ret, c)
+				if mask != 0 && utf8.RuneStart(c) {
+					io.WriteString(w, string(mask))
+				}
+			}
+			buf[0] = 0
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(ret) > 0 {
+				return ret, nil
+			}
+			wipe(ret)
+			return nil, err
+		}
+	}
+}
+
+// ReadPasswordWithOptions temporarily changes the prompt and reads a password
+// like ReadPassword, with options to show a mask for each character typed and
+// to limit the time the user has. Nothing else is kept of the password, so
+// the caller can wipe it by zeroing the returned slice, such as with clear,
+// once it has been used.
+func (t *Terminal) ReadPasswordWithOptions(prompt string, opts PasswordOptions) ([]byte, error) {
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	oldPrompt, oldHighlighter := t.prompt, t.Highlighter
+	t.prompt = []rune(prompt)
+	t.Highlighter = nil
+	t.readCtx = ctx
+	defer func() {
+		t.prompt, t.Highlighter = oldPrompt, oldHighlighter
+		t.readCtx = nil
+	}()
+
+	return t.readPassword(opts.Mask)
+}
+
+// readPassword reads a password, showing mask for each character if it is
+// not zero. The line being edited only ever holds the masks. t.lock must be
+// held.
+func (t *Terminal) readPassword(mask rune) ([]byte, error) {
+	t.discardLateInput()
+	t.writeLine(t.displayPrompt())
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+
+	var secret []byte
+	var runes []int
+	for {
+		key, err := t.nextKey()
+		if err == nil && key == keyCtrlC {
+			if t.lineInterrupt {
+				err = ErrInterrupted
+			} else {
+				err = io.EOF
+			}
+		}
+		if err == nil && key == keyCtrlD && len(secret) == 0 {
+			err = io.EOF
+		}
+		if err != nil || key == keyEnter {
+			t.moveCursorToPos(len(t.line))
+			t.queue([]rune("\r\n"))
+			t.moveProgressBelow()
+			t.line = t.line[:0]
+			t.pos = 0
+			t.cursorX, t.cursorY, t.maxLine = 0, 0, 0
+			t.c.Write(t.outBuf)
+			t.outBuf = t.outBuf[:0]
+			// The input that was read is wiped, leaving what was
+			// typed after the password.
+			wipe(t.inBuf[len(t.remainder):])
+			if err != nil {
+				wipe(secret)
+				return nil, err
+			}
+			if secret == nil {
+				secret = []byte{}
+			}
+			return secret, nil
+		}
+
+		switch {
+		case key == keyBackspace || key == 8:
+			if len(runes) == 0 {
+				break
+			}
+			size := runes[len(runes)-1]
+			runes = runes[:len(runes)-1]
+			wipe(secret[len(secret)-size:])
+			secret = secret[:len(secret)-size]
+			if mask != 0 {
+				t.eraseNPreviousChars(1)
+			}
+		case key == keyCtrlU:
+			wipe(secret)
+			secret, runes = secret[:0], runes[:0]
+			if mask != 0 {
+				t.setLine(t.line[:0], 0)
+			}
+		case isPrintable(key) && key < keyUnknown:
+			var encoded [utf8.UTFMax]byte
+			n := utf8.EncodeRune(encoded[:], key)
+			secret = appendSecret(secret, encoded[:n]...)
+			wipe(encoded[:])
+			runes = append(runes, n)
+			if mask != 0 {
+				t.addKeyToLine(mask)
+			}
+		}
+		t.c.Write(t.outBuf)
+		t.outBuf = t.outBuf[:0]
+	}
+}
+
+// readResult is the result of a read from the terminal that continues in
+// the background.
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// read reads input from the terminal into buf. t.lock must be held; it is
+// released while waiting. While t.readCtx is set, the wait ends with its error
+// once it is done. The read then continues in the background, and the input
+// it returns before the next prompt is shown is wiped and discarded, as it
+// was typed for the prompt that ended, which may have been asking for a
+// password.
+func (t *Terminal) read(buf []byte) (int, error) {
+	t.discardLateInput()
+	for {
+		if t.readCtx == nil && t.pendingRead == nil {
+			t.lock.Unlock()
+			n, err := t.c.Read(buf)
+			t.lock.Lock()
+			return n, err
+		}
+
+		if t.pendingRead == nil {
+			pending := make(chan readResult, 1)
+			data := make([]byte, len(buf))
+			go func() {
+				n, err := t.c.Read(data)
+				pending <- readResult{data[:n], err}
+			}()
+			t.pendingRead = pending
+		}
+		var done <-chan struct{}
+		if t.readCtx != nil {
+			done = t.readCtx.Done()
+		}
+		pending := t.pendingRead
+
+		t.lock.Unlock()
+		select {
+		case r := <-pending:
+			t.lock.Lock()
+			t.pendingRead = nil
+			n := copy(buf, r.data)
+			wipe(r.data[:n])
+			if n < len(r.data) {
+				// Keep what doesn't fit for the next read.
+				rest := make(chan readResult, 1)
+				rest <- readResult{r.data[n:], r.err}
+				t.pendingRead = rest
+				return n, nil
+			}
+			return n, r.err
+		case <-done:
+			t.lock.Lock()
+			t.discardRead = true
+			return 0, t.readCtx.Err()
+		}
+	}
+}
+
+// discardLateInput wipes and drops the input that arrived after a prompt
+// ended and before the next one is shown. Input that arrives later was typed
+// for the new prompt. t.lock must be held.
+func (t *Terminal) discardLateInput() {
+	if !t.discardRead {
+		return
+	}
+	t.discardRead = false
+	select {
+	case r := <-t.pendingRead:
+		t.pendingRead = nil
+		wipe(r.data)
+		if r.err != nil {
+			rest := make(chan readResult, 1)
+			rest <- readResult{nil, r.err}
+			t.pendingRead = rest
+		}
+	default:
+	}
+}
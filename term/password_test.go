@@ -0,0 +1,212 @@
+package term
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadPasswordMask(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\x7fc\x15xy\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	password, err := ss.ReadPasswordWithOptions("Password: ", PasswordOptions{Mask: '*'})
+	if string(password) != "xy" || err != nil {
+		t.Fatalf("got %q, %v, expected xy", password, err)
+	}
+	expected := "Password: **\x1b[D \x1b[D*\x1b[2D  \x1b[2D**\r\n"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+	if strings.Contains(string(ss.line[:cap(ss.line)]), "x") {
+		t.Errorf("password is kept in the line")
+	}
+
+	c.toSend = []byte("\x1b[A\r")
+	if line, _ := ss.ReadLine(); line != "" {
+		t.Errorf("password was saved in history: %q", line)
+	}
+}
+
+func TestReadPasswordWipesInput(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("secret\rnext")}
+	ss := NewTerminal(c, "> ")
+	password, err := ss.ReadPasswordWithOptions("Password: ", PasswordOptions{})
+	if string(password) != "secret" || err != nil {
+		t.Fatalf("got %q, %v, expected secret", password, err)
+	}
+	if string(ss.remainder) != "next" {
+		t.Errorf("input after the password was %q, expected next", ss.remainder)
+	}
+	if rest := ss.inBuf[len(ss.remainder):]; !bytes.Equal(rest, make([]byte, len(rest))) {
+		t.Errorf("input buffer was not wiped: %q", bytes.TrimRight(rest, "\x00"))
+	}
+}
+
+type blockingIO struct {
+	*io.PipeReader
+	io.Writer
+}
+
+// promptWriter records the output of a terminal and reports when a prompt
+// is written.
+type promptWriter struct {
+	lock   sync.Mutex
+	out    bytes.Buffer
+	prompt chan struct{}
+}
+
+func (w *promptWriter) Write(b []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.prompt != nil && bytes.Contains(b, []byte("> ")) {
+		close(w.prompt)
+		w.prompt = nil
+	}
+	return w.out.Write(b)
+}
+
+// nextPrompt returns a channel that is closed once the next prompt has been
+// written.
+func (w *promptWriter) nextPrompt() <-chan struct{} {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.prompt = make(chan struct{})
+	return w.prompt
+}
+
+func (w *promptWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.out.String()
+}
+
+// timedOutTerminal returns a terminal on which a password prompt has timed
+// out, and the writer of its input.
+func timedOutTerminal(t *testing.T) (*Terminal, *io.PipeWriter, *promptWriter) {
+	r, w := io.Pipe()
+	out := &promptWriter{}
+	ss := NewTerminal(blockingIO{r, out}, "> ")
+
+	_, err := ss.ReadPasswordWithOptions("Password: ", PasswordOptions{Timeout: 10 * time.Millisecond})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got %v, expected the deadline to pass", err)
+	}
+	return ss, w, out
+}
+
+func TestReadPasswordTimeout(t *testing.T) {
+	ss, w, out := timedOutTerminal(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ss.ReadPasswordWithOptions("Password: ", PasswordOptions{Context: ctx}); err != context.Canceled {
+		t.Fatalf("got %v, expected the context to be canceled", err)
+	}
+
+	// A password typed after the timeout, before the next prompt is shown,
+	// is discarded, so it doesn't show up on the next line.
+	w.Write([]byte("secret"))
+	// Let the read in the background deliver it.
+	time.Sleep(10 * time.Millisecond)
+	prompt := out.nextPrompt()
+	go func() {
+		<-prompt
+		w.Write([]byte("next\r"))
+	}()
+	line, err := ss.ReadLine()
+	if line != "next" || err != nil {
+		t.Errorf("got %q, %v, expected the input after the discarded read", line, err)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("late password was echoed: %q", out.String())
+	}
+}
+
+func TestReadLineAfterPasswordTimeout(t *testing.T) {
+	ss, w, out := timedOutTerminal(t)
+
+	// The read that continues in the background gets the first keys typed
+	// at the next prompt, which are kept.
+	prompt := out.nextPrompt()
+	go func() {
+		<-prompt
+		w.Write([]byte("l"))
+		w.Write([]byte("s\r"))
+	}()
+	line, err := ss.ReadLine()
+	if line != "ls" || err != nil {
+		t.Errorf("got %q, %v, expected ls", line, err)
+	}
+}
+
+// slowReader returns no input on each read until it has been read n times.
+type slowReader struct {
+	n  int
+	in *bytes.Reader
+}
+
+func (r *slowReader) Read(buf []byte) (int, error) {
+	if r.n > 0 {
+		r.n--
+		return 0, nil
+	}
+	return r.in.Read(buf)
+}
+
+func TestReadPasswordKeys(t *testing.T) {
+	tests := []struct {
+		in, want, echo string
+		err            error
+	}{
+		{"secret\n", "secret", "******", nil},
+		{"ab\x7fc\r", "ac", "**\b \b*", nil},
+		{"ab\bc\r", "ac", "**\b \b*", nil},
+		{"héllo\x7f\x7f\r", "hél", "*****\b \b\b \b", nil},
+		{"abc\x15d\n", "d", "***\b \b\b \b\b \b*", nil},
+		{"\x04", "", "", io.EOF},
+		{"ab", "ab", "**", nil},
+	}
+	for _, test := range tests {
+		out := new(bytes.Buffer)
+		got, err := readPasswordKeys(nil, strings.NewReader(test.in), out, '*')
+		if string(got) != test.want || err != test.err {
+			t.Errorf("readPasswordKeys(%q) returned %q, %v, expected %q, %v", test.in, got, err, test.want, test.err)
+		}
+		if out.String() != test.echo {
+			t.Errorf("readPasswordKeys(%q) wrote %q, expected %q", test.in, out.String(), test.echo)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &slowReader{n: 3, in: bytes.NewReader([]byte("late\n"))}
+	cancel()
+	if _, err := readPasswordKeys(ctx, r, io.Discard, 0); err != context.Canceled {
+		t.Errorf("got %v, expected the context to end the prompt", err)
+	}
+	if got, err := readPasswordKeys(context.Background(), r, io.Discard, 0); string(got) != "late" || err != nil {
+		t.Errorf("got %q, %v, expected late", got, err)
+	}
+}
+
+func TestAppendSecret(t *testing.T) {
+	secret := make([]byte, 0, 2)
+	secret = appendSecret(secret, 'a', 'b')
+	old := secret
+	secret = appendSecret(secret, 'c')
+	if string(secret) != "abc" {
+		t.Errorf("got %q, expected abc", secret)
+	}
+	if old[0] != 0 || old[1] != 0 {
+		t.Errorf("old buffer was not wiped: %q", old)
+	}
+}
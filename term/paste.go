@@ -0,0 +1,103 @@
+package term
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// SetPasteBlock sets whether a bracketed paste is inserted into the input as
+// a single block, keeping its newlines, instead of being handled like typed
+// keys where every pasted newline ends a line. A paste that ends with a
+// newline is entered, so ReadLine returns it whole, with ErrPasteIndicator if
+// nothing but the paste was entered. The limit set with SetMaxPasteSize and
+// PasteConfirmCallback apply to paste blocks. Bracketed paste must be enabled
+// with SetBracketedPasteMode.
+func (t *Terminal) SetPasteBlock(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.pasteBlock = on
+}
+
+// SetMaxPasteSize sets the largest paste block, in bytes, that is accepted.
+// Larger pastes are discarded with a message shown above the prompt. A size
+// that is not positive sets the default, which is the maximum line length.
+func (t *Terminal) SetMaxPasteSize(size int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.maxPasteSize = size
+}
+
+// pasteKey adds a key of a paste block to the paste. Carriage returns are
+// turned into newlines, and nothing more is kept once the paste is too large.
+func (t *Terminal) pasteKey(key rune) {
+	if key == '\n' && t.pasteCR {
+		// The newline of a CR LF pair.
+		t.pasteCR = false
+		return
+	}
+	t.pasteCR = key == keyEnter
+	if key == keyEnter {
+		key = '\n'
+	}
+
+	t.pasteSize += utf8.RuneLen(key)
+	if t.pasteSize <= t.pasteLimit() {
+		t.pasteBuf = append(t.pasteBuf, key)
+	}
+}
+
+// pasteLimit returns the largest paste block accepted, in bytes.
+func (t *Terminal) pasteLimit() int {
+	if t.maxPasteSize > 0 {
+		return t.maxPasteSize
+	}
+	return maxLineLength
+}
+
+// endPaste inserts a paste block into the input at the end of the paste. It
+// returns the entered line if the paste ended with a newline. t.lock must be
+// held; it is released while PasteConfirmCallback runs.
+func (t *Terminal) endPaste() (line string, ok bool) {
+	text, size := t.pasteBuf, t.pasteSize
+	t.pasteBuf, t.pasteSize, t.pasteCR = nil, 0, false
+
+	if limit := t.pasteLimit(); size > limit {
+		t.writeAbove([]byte("paste of " + strconv.Itoa(size) + " bytes rejected, the limit is " + strconv.Itoa(limit) + " bytes\n"))
+		return "", false
+	}
+
+	enter := len(text) > 0 && text[len(text)-1] == '\n'
+	if enter {
+		text = text[:len(text)-1]
+	}
+	if len(text) == 0 && !enter {
+		return "", false
+	}
+
+	if isMultiLine(text) && t.PasteConfirmCallback != nil {
+		t.lock.Unlock()
+		accepted := t.PasteConfirmCallback(string(text))
+		t.lock.Lock()
+
+		if !accepted {
+			return "", false
+		}
+	}
+
+	newLine := make([]rune, 0, len(t.line)+len(text))
+	newLine = append(newLine, t.line[:t.pos]...)
+	newLine = append(newLine, text...)
+	newLine = append(newLine, t.line[t.pos:]...)
+	t.setLine(newLine, t.pos+len(text))
+	// The paste is undone on its own.
+	t.prevCommand, t.lastCommand = t.lastCommand, commandOther
+	t.recordUndo()
+
+	if enter {
+		return t.handleKey(keyEnter)
+	}
+	t.updateHint()
+	return "", false
+}
@@ -0,0 +1,117 @@
+package term
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+var pasteBlockTests = []struct {
+	in   string
+	line string
+	err  error
+}{
+	// Newlines in a paste are kept, and a paste ending in a newline is
+	// entered.
+	{in: "\x1b[200~abc\rdef\r\x1b[201~", line: "abc\ndef", err: ErrPasteIndicator},
+	{in: "\x1b[200~abc\r\ndef\r\n\x1b[201~", line: "abc\ndef", err: ErrPasteIndicator},
+	{in: "\x1b[200~abc\ndef\x1b[201~\r", line: "abc\ndef"},
+	// The paste is inserted at the cursor.
+	{in: "xy\x1b[D\x1b[200~a\rb\x1b[201~\r", line: "xa\nby"},
+	// The paste is undone as a whole.
+	{in: "xy\x1b[200~a\rb\x1b[201~\x1f\r", line: "xy"},
+}
+
+func TestPasteBlock(t *testing.T) {
+	for i, test := range pasteBlockTests {
+		for j := 1; j < len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			ss.SetPasteBlock(true)
+			line, err := ss.ReadLine()
+			if line != test.line || err != test.err {
+				t.Errorf("#%d (%d bytes per read): got %q, %v, expected %q, %v", i, j, line, err, test.line, test.err)
+				break
+			}
+		}
+	}
+}
+
+func TestMaxPasteSize(t *testing.T) {
+	c := &MockTerminal{
+		toSend: []byte("ab\x1b[200~0123456789\x1b[201~c\r"),
+	}
+	ss := NewTerminal(c, "> ")
+	ss.SetPasteBlock(true)
+	ss.SetMaxPasteSize(8)
+	line, err := ss.ReadLine()
+	if line != "abc" || err != nil {
+		t.Errorf("got %q, %v, expected the paste to be rejected", line, err)
+	}
+	if !strings.Contains(string(c.received), "paste of 10 bytes rejected, the limit is 8 bytes\r\n> ab") {
+		t.Errorf("rejection is not shown: %q", c.received)
+	}
+
+	c = &MockTerminal{
+		toSend: []byte("\x1b[200~" + strings.Repeat("x", 5000) + "\x1b[201~\r"),
+	}
+	ss = NewTerminal(c, "> ")
+	ss.SetPasteBlock(true)
+	if line, _ := ss.ReadLine(); line != "" {
+		t.Errorf("paste longer than the maximum line length was inserted: %d bytes", len(line))
+	}
+}
+
+func TestPasteConfirmCallback(t *testing.T) {
+	var pasted []string
+	accept := false
+	c := &MockTerminal{
+		toSend: []byte("\x1b[200~one\rtwo\r\x1b[201~\x1b[200~three\x1b[201~\r"),
+	}
+	ss := NewTerminal(c, "> ")
+	ss.SetPasteBlock(true)
+	ss.PasteConfirmCallback = func(text string) bool {
+		pasted = append(pasted, text)
+		return accept
+	}
+	line, err := ss.ReadLine()
+	if line != "three" || err != nil {
+		t.Errorf("got %q, %v, expected the multi-line paste to be discarded", line, err)
+	}
+	if len(pasted) != 1 || pasted[0] != "one\ntwo" {
+		t.Errorf("callback was called with %q", pasted)
+	}
+
+	accept = true
+	c.toSend = []byte("\x1b[200~one\rtwo\r\x1b[201~")
+	if line, _ := ss.ReadLine(); line != "one\ntwo" {
+		t.Errorf("got %q after the paste was confirmed", line)
+	}
+}
+
+func TestPasteConfirmReadsInput(t *testing.T) {
+	c := &MockTerminal{
+		toSend: []byte("\x1b[200~one\rtwo\r\x1b[201~y"),
+	}
+	ss := NewTerminal(c, "> ")
+	ss.SetPasteBlock(true)
+	var confirmed bool
+	var confirmErr error
+	ss.PasteConfirmCallback = func(text string) bool {
+		confirmed, confirmErr = ss.Confirm("run?", false)
+		return confirmed
+	}
+	line, err := ss.ReadLine()
+	if !confirmed || confirmErr != nil {
+		t.Errorf("Confirm returned %v, %v, expected the typed y", confirmed, confirmErr)
+	}
+	if line != "one\ntwo" || err != ErrPasteIndicator {
+		t.Errorf("got %q, %v, expected the confirmed paste", line, err)
+	}
+	if line, err := ss.ReadLine(); line != "" || err != io.EOF {
+		t.Errorf("got %q, %v after the paste, expected EOF", line, err)
+	}
+}
@@ -0,0 +1,403 @@
+package term
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrScreenActive is returned by EnterScreen when the terminal already
+	// shows a Screen.
+	ErrScreenActive = errors.New("term: screen already active")
+	// ErrScreenUnsupported is returned by EnterScreen when the terminal
+	// doesn't support escape sequences.
+	ErrScreenUnsupported = errors.New("term: terminal does not support a full screen")
+	// ErrScreenClosed is returned when a Screen is used after Close.
+	ErrScreenClosed = errors.New("term: screen closed")
+)
+
+// Cell is a character cell of a Screen.
+type Cell struct {
+	// Text is the glyph shown in the cell, a single grapheme cluster. An
+	// empty Text shows a space. A wide glyph covers the cell to its right
+	// too.
+	Text  string
+	Style Style
+
+	// covered is true for the right half of a wide glyph.
+	covered bool
+}
+
+// Screen is a full screen view on the alternate screen buffer of the
+// terminal, for programs such as dashboards that take over the screen. It is
+// drawn into a buffer of cells, and Show repaints the cells that changed. The
+// line editor, its prompt and the output written above it are left as they
+// are on the normal screen and come back when the Screen is closed. Output
+// written to the terminal while the Screen is shown is held until then.
+type Screen struct {
+	t             *Terminal
+	width, height int
+	// cells is the content drawn by the program and shown the content the
+	// terminal shows. shown is nil if the whole screen is to be repainted.
+	cells, shown []Cell
+
+	cursorX, cursorY int
+	// cursor is true if the cursor is to be shown and cursorShown if the
+	// terminal shows it.
+	cursor, cursorShown bool
+
+	// editorWidth is the width of the terminal when the screen was
+	// entered and hadStatus is true if a status line was shown.
+	editorWidth int
+	hadStatus   bool
+	closed      bool
+}
+
+// EnterScreen switches the terminal to the alternate screen buffer, clears it
+// and hides the cursor. The returned Screen must be closed to return to the
+// line editor.
+func (t *Terminal) EnterScreen() (*Screen, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.screen != nil {
+		return nil, ErrScreenActive
+	}
+	if !t.SupportsANSI() {
+		return nil, ErrScreenUnsupported
+	}
+
+	s := &Screen{
+		t:           t,
+		editorWidth: t.termWidth,
+		hadStatus:   len(t.status) > 0,
+	}
+	s.resize(t.termWidth, t.termHeight)
+	t.screen = s
+
+	t.queue([]rune("\x1b[?1049h"))
+	if s.hadStatus {
+		// The status line's scrolling region would apply to the
+		// alternate screen too.
+		t.queue([]rune("\x1b[r"))
+	}
+	t.queue([]rune("\x1b[?25l\x1b[H\x1b[2J"))
+	_, err := t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+	return s, err
+}
+
+// Close leaves the alternate screen and shows the cursor. The line editor is
+// redrawn if the terminal was resized in the meantime, and the output held
+// while the screen was shown is written above the prompt and the progress
+// lines. Closing a closed
+// Screen does nothing.
+func (s *Screen) Close() error {
+	t := s.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	t.screen = nil
+
+	t.queue([]rune("\x1b[0m\x1b[?25h\x1b[?1049l"))
+	t.reflow(s.editorWidth)
+	if t.menu != nil {
+		t.drawMenu()
+	}
+	if len(t.status) > 0 {
+		t.reserveStatusRow()
+		t.queueStatus()
+	} else if s.hadStatus {
+		t.queueClearStatus()
+	}
+
+	if _, err := t.c.Write(t.outBuf); err != nil {
+		return err
+	}
+	t.outBuf = t.outBuf[:0]
+
+	output := t.screenOutput
+	t.screenOutput = nil
+	if len(output) == 0 && t.progressRows == 0 && len(t.progress) == 0 {
+		return nil
+	}
+	// Progress lines that changed meanwhile are redrawn too.
+	_, err := t.writeAbove(output)
+	return err
+}
+
+// resize changes the size of the screen, keeping the cells that still fit.
+// The whole screen is repainted by the next Show. t.lock must be held.
+func (s *Screen) resize(width, height int) {
+	width, height = max(width, 1), max(height, 1)
+	cells := make([]Cell, width*height)
+	for y := 0; y < min(height, s.height); y++ {
+		copy(cells[y*width:y*width+min(width, s.width)], s.cells[y*s.width:])
+	}
+	if width < s.width {
+		// A wide glyph cut in half at the new right edge is removed.
+		for y := 0; y < height; y++ {
+			if i := y*width + width - 1; cells[i].cellWidth() == 2 {
+				cells[i] = Cell{Style: cells[i].Style}
+			}
+		}
+	}
+	s.width, s.height = width, height
+	s.cells, s.shown = cells, nil
+	s.cursorX, s.cursorY = min(s.cursorX, width-1), min(s.cursorY, height-1)
+}
+
+// cellWidth returns the number of columns the glyph of c takes.
+func (c Cell) cellWidth() int {
+	if c.Text == "" {
+		return 1
+	}
+	_, width := nextGlyph([]rune(c.Text))
+	return max(width, 1)
+}
+
+// Size returns the number of columns and rows of the screen. It changes when
+// the terminal is resized, see Terminal.SetSize.
+func (s *Screen) Size() (width, height int) {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	return s.width, s.height
+}
+
+// Clear blanks all cells.
+func (s *Screen) Clear() {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	for i := range s.cells {
+		s.cells[i] = Cell{}
+	}
+}
+
+// Cell returns the cell at column x and row y, starting with 0 at the top
+// left corner.
+func (s *Screen) Cell(x, y int) Cell {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return Cell{}
+	}
+	c := s.cells[y*s.width+x]
+	c.covered = false
+	return c
+}
+
+// SetCell sets the cell at column x and row y. Only the first glyph of
+// c.Text is used. Cells outside of the screen are ignored.
+func (s *Screen) SetCell(x, y int, c Cell) {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	text, width := "", 1
+	if runes := []rune(c.Text); len(runes) > 0 && runes[0] >= ' ' {
+		n, w := nextGlyph(runes)
+		if w > 0 {
+			text, width = string(runes[:n]), w
+		}
+	}
+	s.set(x, y, text, width, c.Style)
+}
+
+// SetString writes text in style starting at column x of row y and returns
+// the number of columns written. Text that doesn't fit on the row is cut
+// off. Escape sequences and control characters in text are skipped.
+func (s *Screen) SetString(x, y int, text string, style Style) int {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	start := x
+	runes := []rune(text)
+	for len(runes) > 0 && x < s.width {
+		n, width := nextGlyph(runes)
+		glyph := runes[:n]
+		runes = runes[n:]
+		if width == 0 || glyph[0] < ' ' {
+			continue
+		}
+		if x+width > s.width {
+			break
+		}
+		s.set(x, y, string(glyph), width, style)
+		x += width
+	}
+	return x - start
+}
+
+// set sets a cell to a glyph of the given width. t.lock must be held.
+func (s *Screen) set(x, y int, text string, width int, style Style) {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return
+	}
+	if width == 2 && x+1 >= s.width {
+		// A wide glyph doesn't fit in the last column.
+		text, width = "", 1
+	}
+
+	row := s.cells[y*s.width : (y+1)*s.width]
+	// Wide glyphs that are partly overwritten are removed.
+	if row[x].covered {
+		row[x-1] = Cell{Style: row[x-1].Style}
+	}
+	end := x + width
+	if end < s.width && row[end].covered {
+		row[end] = Cell{Style: row[end].Style}
+	}
+
+	row[x] = Cell{Text: text, Style: style}
+	if width == 2 {
+		row[x+1] = Cell{Style: style, covered: true}
+	}
+}
+
+// ShowCursor shows the cursor at column x and row y once the screen is
+// shown.
+func (s *Screen) ShowCursor(x, y int) {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	s.cursor = true
+	s.cursorX = min(max(x, 0), s.width-1)
+	s.cursorY = min(max(y, 0), s.height-1)
+}
+
+// HideCursor hides the cursor once the screen is shown. The cursor is hidden
+// when the screen is entered.
+func (s *Screen) HideCursor() {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	s.cursor = false
+}
+
+// Show updates the terminal to show the cells, writing only the cells that
+// changed since the screen was last shown.
+func (s *Screen) Show() error {
+	t := s.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s.closed {
+		return ErrScreenClosed
+	}
+
+	var buf []byte
+	// style is the sequence of the style written last.
+	var style string
+	x, y := -1, -1
+	for i, c := range s.cells {
+		if c.covered || (s.shown != nil && c == s.shown[i] && !s.wideChanged(i)) {
+			continue
+		}
+		if cx, cy := i%s.width, i/s.width; cx != x || cy != y {
+			buf = append(buf, cursorPosition(cx, cy)...)
+			x, y = cx, cy
+		}
+		if seq := c.Style.Sequence(t.caps); seq != style {
+			if style != "" {
+				buf = append(buf, StyleReset...)
+			}
+			buf = append(buf, seq...)
+			style = seq
+		}
+		if c.Text == "" {
+			buf = append(buf, ' ')
+		} else {
+			buf = append(buf, c.Text...)
+		}
+		x += c.cellWidth()
+	}
+	if style != "" {
+		buf = append(buf, StyleReset...)
+	}
+
+	if s.cursor {
+		buf = append(buf, cursorPosition(s.cursorX, s.cursorY)...)
+		if !s.cursorShown {
+			buf = append(buf, "\x1b[?25h"...)
+		}
+	} else if s.cursorShown {
+		buf = append(buf, "\x1b[?25l"...)
+	}
+	s.cursorShown = s.cursor
+
+	if s.shown == nil {
+		s.shown = make([]Cell, len(s.cells))
+	}
+	copy(s.shown, s.cells)
+
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := t.c.Write(buf)
+	return err
+}
+
+// wideChanged returns true if the cell right of cell i is covered by a wide
+// glyph now or was before, and changed.
+func (s *Screen) wideChanged(i int) bool {
+	if i%s.width == s.width-1 {
+		return false
+	}
+	return (s.cells[i+1].covered || s.shown[i+1].covered) && s.cells[i+1] != s.shown[i+1]
+}
+
+// Redraw repaints the whole screen, such as after other programs wrote to
+// the terminal.
+func (s *Screen) Redraw() error {
+	s.t.lock.Lock()
+	if !s.closed {
+		s.shown = nil
+		s.t.queue([]rune("\x1b[0m\x1b[2J"))
+		s.t.c.Write(s.t.outBuf)
+		s.t.outBuf = s.t.outBuf[:0]
+	}
+	s.t.lock.Unlock()
+
+	return s.Show()
+}
+
+// ReadKey waits for a key press. Input that isn't a key, such as a mouse
+// event, is skipped.
+func (s *Screen) ReadKey() (KeyEvent, error) {
+	t := s.t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for read := false; ; read = true {
+		event, rest, ok := DecodeKey(t.remainder)
+		if !ok && read && len(t.remainder) == 1 && t.remainder[0] == keyEscape {
+			event, rest, ok = KeyEvent{Key: KeyEscape}, nil, true
+		}
+		if ok {
+			t.setRemainder(rest)
+			if event.Key == KeyUnknown {
+				continue
+			}
+			return event, nil
+		}
+
+		readBuf := t.inBuf[len(t.remainder):]
+		n, err := t.read(readBuf)
+		if err != nil {
+			return KeyEvent{}, err
+		}
+		t.remainder = t.inBuf[:n+len(t.remainder)]
+	}
+}
+
+// cursorPosition returns the escape sequence moving the cursor to column x
+// and row y.
+func cursorPosition(x, y int) string {
+	return "\x1b[" + strconv.Itoa(y+1) + ";" + strconv.Itoa(x+1) + "H"
+}
@@ -0,0 +1,158 @@
+package term
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScreenShow(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ")
+	ss.SetSize(10, 3)
+	c.received = nil
+
+	s, err := ss.EnterScreen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := string(c.received), "\x1b[?1049h\x1b[?25l\x1b[H\x1b[2J"; got != expected {
+		t.Errorf("incorrect output on enter: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	s.SetString(1, 0, "hi", Style{})
+	s.Show()
+	blank := strings.Repeat(" ", 10)
+	expected := "\x1b[1;1H hi       " + "\x1b[2;1H" + blank + "\x1b[3;1H" + blank
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect first repaint: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	s.SetString(2, 0, "o", Style{Bold: true})
+	s.Show()
+	if got, expected := string(c.received), "\x1b[1;3H\x1b[1mo\x1b[0m"; got != expected {
+		t.Errorf("incorrect repaint of a changed cell: was %q, expected %q", got, expected)
+	}
+
+	c.received = nil
+	s.Show()
+	if len(c.received) != 0 {
+		t.Errorf("unchanged screen was repainted: %q", c.received)
+	}
+
+	s.ShowCursor(0, 2)
+	s.Show()
+	if got, expected := string(c.received), "\x1b[3;1H\x1b[?25h"; got != expected {
+		t.Errorf("incorrect output for the cursor: was %q, expected %q", got, expected)
+	}
+	if got := s.Cell(2, 0); got.Text != "o" || !got.Style.Bold {
+		t.Errorf("incorrect cell: %+v", got)
+	}
+}
+
+func TestScreenWideGlyphs(t *testing.T) {
+	c := &MockTerminal{}
+	ss := NewTerminal(c, "> ")
+	ss.SetSize(10, 3)
+	s, _ := ss.EnterScreen()
+	s.Show()
+
+	c.received = nil
+	if n := s.SetString(0, 1, "世x", Style{}); n != 3 {
+		t.Errorf("SetString returned %d, expected 3", n)
+	}
+	s.Show()
+	if got, expected := string(c.received), "\x1b[2;1H世x"; got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+
+	// Overwriting the right half of the wide glyph removes it.
+	c.received = nil
+	s.SetString(1, 1, "a", Style{})
+	s.Show()
+	if got, expected := string(c.received), "\x1b[2;1H a"; got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+
+	if n := s.SetString(9, 0, "世", Style{}); n != 0 {
+		t.Errorf("wide glyph was written in the last column")
+	}
+}
+
+func TestScreenClose(t *testing.T) {
+	c, ss := editingTerminal()
+	s, err := ss.EnterScreen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ss.EnterScreen(); err != ErrScreenActive {
+		t.Errorf("second EnterScreen returned %v, expected ErrScreenActive", err)
+	}
+
+	c.received = nil
+	ss.Printf("log")
+	ss.SetStatus("")
+	if len(c.received) != 0 {
+		t.Errorf("output was written to the screen: %q", c.received)
+	}
+
+	s.Close()
+	expected := "\x1b[0m\x1b[?25h\x1b[?1049l" + "\x1b[4D\x1b[Klog\r\n> ab"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on close: was %q, expected %q", got, expected)
+	}
+	if err := s.Show(); err != ErrScreenClosed {
+		t.Errorf("Show after Close returned %v, expected ErrScreenClosed", err)
+	}
+}
+
+func TestScreenResize(t *testing.T) {
+	c, ss := editingTerminal()
+	s, _ := ss.EnterScreen()
+	s.SetString(0, 0, "hello", Style{})
+
+	c.received = nil
+	ss.SetSize(3, 2)
+	if len(c.received) != 0 {
+		t.Errorf("output was written on resize: %q", c.received)
+	}
+	if width, height := s.Size(); width != 3 || height != 2 {
+		t.Errorf("incorrect size %dx%d, expected 3x2", width, height)
+	}
+	s.Show()
+	if got, expected := string(c.received), "\x1b[1;1Hhel\x1b[2;1H   "; got != expected {
+		t.Errorf("incorrect repaint: was %q, expected %q", got, expected)
+	}
+
+	// The prompt wraps at the new width and is repainted.
+	c.received = nil
+	s.Close()
+	expected := "\x1b[0m\x1b[?25h\x1b[?1049l" + "\x1b[2D\x1b[K> a\r\nb"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output on close: was %q, expected %q", got, expected)
+	}
+}
+
+func TestScreenReadKey(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\x1b[Aq")}
+	ss := NewTerminal(c, "> ")
+	s, _ := ss.EnterScreen()
+	for _, expected := range []KeyEvent{{Key: KeyUp}, {Key: 'q'}} {
+		if got, err := s.ReadKey(); got != expected || err != nil {
+			t.Errorf("got %v, %v, expected %v", got, err, expected)
+		}
+	}
+	if _, err := s.ReadKey(); err != io.EOF {
+		t.Errorf("got %v, expected EOF", err)
+	}
+}
+
+func TestScreenUnsupported(t *testing.T) {
+	ss := NewTerminal(&MockTerminal{}, "> ")
+	ss.SetCapabilities(Capabilities{})
+	if _, err := ss.EnterScreen(); err != ErrScreenUnsupported {
+		t.Errorf("got %v, expected ErrScreenUnsupported", err)
+	}
+}
@@ -0,0 +1,203 @@
+package term
+
+import "strings"
+
+// historySearch contains the state of an incremental history search started
+// with Ctrl-R (reverse) or Ctrl-S (forward).
+type historySearch struct {
+	reverse bool
+	query   []rune
+	// index is the history index of the current match, or -1 if nothing
+	// matched yet.
+	index int
+	// failed is true if the last search did not find a match.
+	failed bool
+
+	// prompt, line and pos contain the state from before the search was
+	// started so that it can be restored.
+	prompt []rune
+	line   []rune
+	pos    int
+}
+
+// startSearch starts an incremental history search, or searches for the next
+// match if a search is already active.
+func (t *Terminal) startSearch(reverse bool) {
+	if t.search == nil {
+		t.search = &historySearch{
+			reverse: reverse,
+			index:   -1,
+			prompt:  t.prompt,
+			line:    append([]rune(nil), t.line...),
+			pos:     t.pos,
+		}
+		t.updateSearch(-1)
+		return
+	}
+
+	s := t.search
+	s.reverse = reverse
+	if len(s.query) == 0 && len(t.lastSearch) > 0 {
+		s.query = append(s.query, t.lastSearch...)
+		t.updateSearch(-1)
+		return
+	}
+	if s.reverse {
+		t.updateSearch(s.index + 1)
+	} else {
+		t.updateSearch(s.index - 1)
+	}
+}
+
+// updateSearch looks for the query in the history starting at the history
+// index from, and redraws the search prompt and match. A negative from
+// restarts the search with the most recent entry.
+func (t *Terminal) updateSearch(from int) {
+	s := t.search
+	if from < 0 {
+		from = 0
+		if !s.reverse && s.index >= 0 {
+			from = s.index
+		}
+	}
+
+	s.failed = false
+	if len(s.query) > 0 {
+		query := string(s.query)
+		found := false
+		for i := from; i >= 0 && i < t.history.size; {
+			entry, _ := t.history.NthPreviousEntry(i)
+			if strings.Contains(entry, query) {
+				s.index = i
+				found = true
+				break
+			}
+			if s.reverse {
+				i++
+			} else {
+				i--
+			}
+		}
+		s.failed = !found
+	}
+
+	line := s.line
+	pos := s.pos
+	if s.index >= 0 {
+		entry, _ := t.history.NthPreviousEntry(s.index)
+		line = []rune(entry)
+		pos = len(line)
+		if i := strings.Index(entry, string(s.query)); i >= 0 && len(s.query) > 0 {
+			pos = len([]rune(entry[:i]))
+		}
+	}
+
+	prompt := "(reverse-i-search)`"
+	if !s.reverse {
+		prompt = "(i-search)`"
+	}
+	if s.failed {
+		prompt = "(failed " + prompt[1:]
+	}
+	t.prompt = []rune(prompt + string(s.query) + "': ")
+	t.line = append(t.line[:0], line...)
+	t.pos = pos
+	t.clearAndRepaintLinePlusNPrevious(t.maxLine)
+}
+
+// endSearch leaves the search mode. If accept is true the current match is
+// left on the line, otherwise the line from before the search is restored.
+func (t *Terminal) endSearch(accept bool) {
+	s := t.search
+	t.search = nil
+	if len(s.query) > 0 {
+		t.lastSearch = s.query
+	}
+
+	t.prompt = s.prompt
+	if accept && s.index >= 0 {
+		t.historyIndex = s.index
+		t.historyPending = string(s.line)
+	} else {
+		t.line = append(t.line[:0], s.line...)
+		t.pos = s.pos
+	}
+	t.clearAndRepaintLinePlusNPrevious(t.maxLine)
+}
+
+// resetSearch restores the prompt if a search is active without redrawing
+// the line. It is used when readLine returns early.
+func (t *Terminal) resetSearch() {
+	if t.search != nil {
+		t.prompt = t.search.prompt
+		t.search = nil
+	}
+}
+
+// handleSearchKey processes a key while a search is active. If the key ends
+// the search and should be processed normally, handled is false.
+func (t *Terminal) handleSearchKey(key rune) (handled bool) {
+	s := t.search
+	switch key {
+	case keyCtrlR:
+		t.startSearch(true)
+	case keyCtrlS:
+		t.startSearch(false)
+	case keyCtrlG:
+		t.endSearch(false)
+	case keyEscape:
+		t.endSearch(true)
+	case keyBackspace:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+			s.index = -1
+			t.updateSearch(-1)
+		}
+	default:
+		if !isPrintable(key) || key >= keyUnknown {
+			t.endSearch(true)
+			return false
+		}
+		s.query = append(s.query, key)
+		if s.index < 0 {
+			t.updateSearch(-1)
+		} else {
+			t.updateSearch(s.index)
+		}
+	}
+	return true
+}
+
+// searchHistoryPrefix replaces the line with the next older, or if backward
+// is false newer, history entry that starts with the text before the cursor.
+// The cursor stays in place.
+func (t *Terminal) searchHistoryPrefix(backward bool) {
+	prefix := string(t.line[:t.pos])
+	for i := t.historyIndex; ; {
+		if backward {
+			i++
+		} else {
+			i--
+		}
+		if i < 0 {
+			if t.historyIndex >= 0 {
+				t.historyIndex = -1
+				t.setLine([]rune(t.historyPending), min(t.pos, len([]rune(t.historyPending))))
+			}
+			return
+		}
+		entry, ok := t.history.NthPreviousEntry(i)
+		if !ok {
+			return
+		}
+		if !strings.HasPrefix(entry, prefix) || entry == string(t.line) {
+			continue
+		}
+		if t.historyIndex == -1 {
+			t.historyPending = string(t.line)
+		}
+		t.historyIndex = i
+		t.setLine([]rune(entry), t.pos)
+		return
+	}
+}
@@ -0,0 +1,316 @@
+package term
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorLevel is the number of colors a terminal can display.
+type ColorLevel int
+
+// Color levels, from no styling at all to 24 bit colors.
+const (
+	ColorsNone ColorLevel = iota
+	Colors16
+	Colors256
+	ColorsTrue
+)
+
+// Capabilities describes the styles a terminal can display.
+type Capabilities struct {
+	// Colors is the color level of the terminal. With ColorsNone no
+	// escape sequences are written at all.
+	Colors ColorLevel
+	// Hyperlinks is true if the terminal shows OSC 8 hyperlinks.
+	Hyperlinks bool
+}
+
+// DetectCapabilities returns the capabilities of a terminal from the values
+// of TERM and COLORTERM, as found in the environment of a local program or
+// the pty request and environment of an SSH session. Hyperlinks are assumed
+// for terminals with at least 256 colors, as other terminals are mostly
+// older ones that may show the escape sequences.
+func DetectCapabilities(termName, colorTerm string) Capabilities {
+	termName = strings.ToLower(termName)
+	colorTerm = strings.ToLower(colorTerm)
+
+	var caps Capabilities
+	switch {
+	case termName == "" || termName == "dumb":
+		return caps
+	case colorTerm == "truecolor" || colorTerm == "24bit" || strings.HasSuffix(termName, "-direct"):
+		caps.Colors = ColorsTrue
+	case strings.Contains(termName, "256color"):
+		caps.Colors = Colors256
+	default:
+		caps.Colors = Colors16
+	}
+	caps.Hyperlinks = caps.Colors >= Colors256 && termName != "linux" && !strings.HasPrefix(termName, "screen")
+	return caps
+}
+
+// EnvCapabilities returns the capabilities of the terminal of the program
+// from the TERM and COLORTERM environment variables. Setting NO_COLOR turns
+// styling off.
+func EnvCapabilities() Capabilities {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return Capabilities{}
+	}
+	return DetectCapabilities(os.Getenv("TERM"), os.Getenv("COLORTERM"))
+}
+
+// Color is a foreground or background color. The zero value is the default
+// color of the terminal.
+type Color uint32
+
+// The kind of a Color is kept in its top byte.
+const (
+	colorBasic   Color = 1 << 24
+	colorPalette Color = 2 << 24
+	colorRGB     Color = 3 << 24
+	colorKind    Color = 0xff << 24
+)
+
+// The 16 basic colors.
+const (
+	Black Color = colorBasic + iota
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	White
+	BrightBlack
+	BrightRed
+	BrightGreen
+	BrightYellow
+	BrightBlue
+	BrightMagenta
+	BrightCyan
+	BrightWhite
+)
+
+// DefaultColor is the default foreground or background color.
+const DefaultColor Color = 0
+
+// PaletteColor returns color n of the 256 color palette.
+func PaletteColor(n uint8) Color {
+	return colorPalette | Color(n)
+}
+
+// RGB returns a 24 bit color.
+func RGB(r, g, b uint8) Color {
+	return colorRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// basicRGB contains the colors of the xterm default palette for the 16 basic
+// colors.
+var basicRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// rgb returns the red, green and blue values of c.
+func (c Color) rgb() (r, g, b int) {
+	n := int(c & 0xffffff)
+	switch c & colorKind {
+	case colorRGB:
+		return n >> 16, n >> 8 & 0xff, n & 0xff
+	case colorBasic:
+		return basicRGB[n][0], basicRGB[n][1], basicRGB[n][2]
+	}
+	switch {
+	case n < 16:
+		return basicRGB[n][0], basicRGB[n][1], basicRGB[n][2]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return level(n / 36), level(n / 6 % 6), level(n % 6)
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+// degrade returns the nearest color to c that can be shown with colors.
+func (c Color) degrade(colors ColorLevel) Color {
+	kind := c & colorKind
+	switch {
+	case c == DefaultColor || colors == ColorsTrue || kind == colorBasic:
+		return c
+	case kind == colorPalette && colors == Colors256:
+		return c
+	case kind == colorPalette && c&0xff < 16:
+		return colorBasic | c&0xff
+	}
+
+	r, g, b := c.rgb()
+	if colors == Colors256 {
+		if r == g && g == b {
+			if r < 8 {
+				return PaletteColor(16)
+			}
+			if r > 238 {
+				return PaletteColor(231)
+			}
+			return PaletteColor(uint8(232 + (r-8)/10))
+		}
+		cube := func(v int) int { return (v*5 + 127) / 255 }
+		return PaletteColor(uint8(16 + 36*cube(r) + 6*cube(g) + cube(b)))
+	}
+
+	best, bestDist := 0, -1
+	for i, basic := range basicRGB {
+		dr, dg, db := r-basic[0], g-basic[1], b-basic[2]
+		if dist := dr*dr + dg*dg + db*db; bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return colorBasic | Color(best)
+}
+
+// sgr appends the SGR parameters selecting c as foreground color, or as
+// background color if background is true.
+func (c Color) sgr(params []string, background bool) []string {
+	n := int(c & 0xffffff)
+	switch c & colorKind {
+	case colorBasic:
+		base := 30
+		if background {
+			base = 40
+		}
+		if n >= 8 {
+			base += 60
+			n -= 8
+		}
+		return append(params, strconv.Itoa(base+n))
+	case colorPalette:
+		prefix := "38;5;"
+		if background {
+			prefix = "48;5;"
+		}
+		return append(params, prefix+strconv.Itoa(n))
+	case colorRGB:
+		prefix := "38;2;"
+		if background {
+			prefix = "48;2;"
+		}
+		return append(params, prefix+strconv.Itoa(n>>16)+";"+strconv.Itoa(n>>8&0xff)+";"+strconv.Itoa(n&0xff))
+	}
+	return params
+}
+
+// Style is a set of text attributes and colors.
+type Style struct {
+	Bold, Dim, Italic, Underline, Reverse bool
+	Fg, Bg                                Color
+}
+
+// StyleReset is the escape sequence that resets all attributes and colors.
+const StyleReset = "\x1b[0m"
+
+// Sequence returns the escape sequence that starts writing in style s on a
+// terminal with the capabilities c. Colors are degraded to the nearest color
+// the terminal can show. It returns an empty string for the zero Style and
+// for terminals without styling.
+func (s Style) Sequence(c Capabilities) string {
+	if c.Colors == ColorsNone {
+		return ""
+	}
+
+	var params []string
+	for _, attr := range []struct {
+		on    bool
+		param string
+	}{
+		{s.Bold, "1"},
+		{s.Dim, "2"},
+		{s.Italic, "3"},
+		{s.Underline, "4"},
+		{s.Reverse, "7"},
+	} {
+		if attr.on {
+			params = append(params, attr.param)
+		}
+	}
+	params = s.Fg.degrade(c.Colors).sgr(params, false)
+	params = s.Bg.degrade(c.Colors).sgr(params, true)
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// Render returns text written in style s followed by a reset, or text as it
+// is if the terminal doesn't support styles.
+func (c Capabilities) Render(s Style, text string) string {
+	seq := s.Sequence(c)
+	if seq == "" {
+		return text
+	}
+	return seq + text + StyleReset
+}
+
+// Hyperlink returns text as a hyperlink to url. Terminals that don't show
+// hyperlinks get text on its own.
+func (c Capabilities) Hyperlink(url, text string) string {
+	if !c.Hyperlinks {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// SetCapabilities sets the styles the terminal can display. A terminal
+// without styling also gets empty escape codes.
+func (t *Terminal) SetCapabilities(c Capabilities) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.caps = c
+	if c.Colors == ColorsNone {
+		t.Escape = &EscapeCodes{}
+	} else {
+		t.Escape = &vt100EscapeCodes
+	}
+}
+
+// SetUTF8 sets whether the terminal shows UTF-8 text beyond ASCII, such as
+// box drawing characters. A server sets it from the locale the client sent,
+// see utilx.LocaleIsUTF8, as the locale of the server process says nothing
+// about the client's terminal.
+func (t *Terminal) SetUTF8(on bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.asciiOnly = !on
+}
+
+// SupportsUTF8 returns true if the terminal shows UTF-8 text beyond ASCII. It
+// is true unless turned off with SetUTF8.
+func (t *Terminal) SupportsUTF8() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return !t.asciiOnly
+}
+
+// Capabilities returns the styles the terminal can display. By default a
+// terminal supports 16 colors and no hyperlinks.
+func (t *Terminal) Capabilities() Capabilities {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.SupportsANSI() {
+		return Capabilities{}
+	}
+	return t.caps
+}
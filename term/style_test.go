@@ -0,0 +1,94 @@
+package term
+
+import "testing"
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		term, colorTerm string
+		caps            Capabilities
+	}{
+		{"", "", Capabilities{}},
+		{"dumb", "truecolor", Capabilities{}},
+		{"vt100", "", Capabilities{Colors: Colors16}},
+		{"xterm", "", Capabilities{Colors: Colors16}},
+		{"xterm-256color", "", Capabilities{Colors: Colors256, Hyperlinks: true}},
+		{"screen-256color", "", Capabilities{Colors: Colors256}},
+		{"xterm-256color", "truecolor", Capabilities{Colors: ColorsTrue, Hyperlinks: true}},
+		{"xterm-direct", "", Capabilities{Colors: ColorsTrue, Hyperlinks: true}},
+	}
+	for _, test := range tests {
+		if caps := DetectCapabilities(test.term, test.colorTerm); caps != test.caps {
+			t.Errorf("DetectCapabilities(%q, %q) returned %+v, expected %+v", test.term, test.colorTerm, caps, test.caps)
+		}
+	}
+}
+
+func TestStyleSequence(t *testing.T) {
+	none := Capabilities{}
+	basic := Capabilities{Colors: Colors16}
+	palette := Capabilities{Colors: Colors256}
+	truecolor := Capabilities{Colors: ColorsTrue}
+
+	tests := []struct {
+		style    Style
+		caps     Capabilities
+		expected string
+	}{
+		{Style{}, truecolor, ""},
+		{Style{Bold: true, Fg: Red}, none, ""},
+		{Style{Bold: true, Fg: Red}, basic, "\x1b[1;31m"},
+		{Style{Dim: true, Italic: true, Underline: true, Reverse: true}, basic, "\x1b[2;3;4;7m"},
+		{Style{Fg: BrightCyan, Bg: Blue}, basic, "\x1b[96;44m"},
+		{Style{Fg: PaletteColor(208)}, palette, "\x1b[38;5;208m"},
+		{Style{Bg: PaletteColor(208)}, basic, "\x1b[43m"},
+		{Style{Fg: PaletteColor(3)}, basic, "\x1b[33m"},
+		{Style{Fg: RGB(255, 128, 0)}, truecolor, "\x1b[38;2;255;128;0m"},
+		{Style{Fg: RGB(255, 128, 0)}, palette, "\x1b[38;5;214m"},
+		{Style{Fg: RGB(128, 128, 128)}, palette, "\x1b[38;5;244m"},
+		{Style{Fg: RGB(250, 250, 250)}, basic, "\x1b[97m"},
+	}
+	for i, test := range tests {
+		if got := test.style.Sequence(test.caps); got != test.expected {
+			t.Errorf("test %d: sequence was %q, expected %q", i, got, test.expected)
+		}
+	}
+}
+
+func TestRenderAndHyperlink(t *testing.T) {
+	caps := Capabilities{Colors: Colors256, Hyperlinks: true}
+	if got, expected := caps.Render(Style{Bold: true}, "go"), "\x1b[1mgo\x1b[0m"; got != expected {
+		t.Errorf("Render returned %q, expected %q", got, expected)
+	}
+
+	link := caps.Hyperlink("https://go.dev", "go")
+	if expected := "\x1b]8;;https://go.dev\x1b\\go\x1b]8;;\x1b\\"; link != expected {
+		t.Errorf("Hyperlink returned %q, expected %q", link, expected)
+	}
+	if got := visualLength([]rune(link)); got != 2 {
+		t.Errorf("visual length of a hyperlink was %d, expected 2", got)
+	}
+	if got := visualLength([]rune("\x1b]0;title\ago")); got != 2 {
+		t.Errorf("visual length of a BEL terminated OSC was %d, expected 2", got)
+	}
+	if got := stripEscapes(link); got != "go" {
+		t.Errorf("stripEscapes returned %q, expected \"go\"", got)
+	}
+	if got := (Capabilities{Colors: Colors256}).Hyperlink("https://go.dev", "go"); got != "go" {
+		t.Errorf("Hyperlink without support returned %q", got)
+	}
+}
+
+func TestSetCapabilities(t *testing.T) {
+	ss := NewTerminal(&MockTerminal{}, "> ")
+	if caps := ss.Capabilities(); caps != (Capabilities{Colors: Colors16}) {
+		t.Errorf("default capabilities were %+v", caps)
+	}
+	ss.SetCapabilities(Capabilities{})
+	if ss.SupportsANSI() {
+		t.Errorf("terminal without styling supports ANSI")
+	}
+	ss.SetCapabilities(Capabilities{Colors: ColorsTrue})
+	if !ss.SupportsANSI() || ss.Capabilities().Colors != ColorsTrue {
+		t.Errorf("capabilities were not set")
+	}
+}
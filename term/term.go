@@ -52,9 +52,30 @@ func GetSize(fd int) (width, height int, err error) {
 	return getSize(fd)
 }
 
+// SetSignals enables or disables the generation of signals, such as SIGINT
+// for Ctrl-C, by a terminal that was put into raw mode. This lets a program
+// interrupt a running command while the terminal otherwise stays raw. On
+// Unix only the interrupt character raises a signal, the quit and suspend
+// characters are disabled until the terminal is restored.
+func SetSignals(fd int, on bool) error {
+	return setSignals(fd, on)
+}
+
 // ReadPassword reads a line of input from a terminal without local echo.  This
 // is commonly used for inputting passwords and other sensitive data. The slice
 // returned does not include the \n.
 func ReadPassword(fd int) ([]byte, error) {
 	return readPassword(fd)
 }
+
+// ReadPasswordWithOptions reads a password from a terminal like ReadPassword,
+// with options to show a mask for each character typed and to limit the time
+// the user has. Backspace erases a character and Ctrl-U the whole password.
+// Buffers that held the password are zeroed, so the caller can wipe it by
+// zeroing the returned slice, such as with clear, once it has been used.
+func ReadPasswordWithOptions(fd int, opts PasswordOptions) ([]byte, error) {
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	return readPasswordOptions(ctx, fd, opts.Mask)
+}
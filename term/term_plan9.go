@@ -5,6 +5,7 @@
 package term
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 
@@ -37,6 +38,14 @@ func getSize(fd int) (width, height int, err error) {
 	return 0, 0, fmt.Errorf("terminal: GetSize not implemented on %s/%s", runtime.GOOS, runtime.GOARCH)
 }
 
+func setSignals(fd int, on bool) error {
+	return fmt.Errorf("terminal: SetSignals not implemented on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
 func readPassword(fd int) ([]byte, error) {
 	return nil, fmt.Errorf("terminal: ReadPassword not implemented on %s/%s", runtime.GOOS, runtime.GOARCH)
 }
+
+func readPasswordOptions(ctx context.Context, fd int, mask rune) ([]byte, error) {
+	return nil, fmt.Errorf("terminal: ReadPasswordWithOptions not implemented on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
@@ -8,6 +8,9 @@
 package term
 
 import (
+	"context"
+	"runtime"
+
 	"golang.org/x/sys/unix"
 )
 
@@ -65,6 +68,33 @@ func getSize(fd int) (width, height int, err error) {
 	return int(ws.Col), int(ws.Row), nil
 }
 
+func setSignals(fd int, on bool) error {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return err
+	}
+	if on {
+		// Only the interrupt character raises a signal: a program that
+		// stays raw has no use for the terminal quitting or suspending it.
+		termios.Lflag |= unix.ISIG
+		termios.Cc[unix.VQUIT] = vdisable()
+		termios.Cc[unix.VSUSP] = vdisable()
+	} else {
+		termios.Lflag &^= unix.ISIG
+	}
+	return unix.IoctlSetTermios(fd, ioctlWriteTermios, termios)
+}
+
+// vdisable returns the value of _POSIX_VDISABLE, which disables a special
+// character of the terminal.
+func vdisable() uint8 {
+	switch runtime.GOOS {
+	case "linux", "solaris", "illumos", "zos":
+		return 0
+	}
+	return 0xff
+}
+
 // passwordReader is an io.Reader that reads from a specific file descriptor.
 type passwordReader int
 
@@ -72,6 +102,11 @@ func (r passwordReader) Read(buf []byte) (int, error) {
 	return unix.Read(int(r), buf)
 }
 
+// Write writes the mask of a password to the terminal.
+func (r passwordReader) Write(buf []byte) (int, error) {
+	return unix.Write(int(r), buf)
+}
+
 func readPassword(fd int) ([]byte, error) {
 	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
 	if err != nil {
@@ -90,3 +125,30 @@ func readPassword(fd int) ([]byte, error) {
 
 	return readPasswordLine(passwordReader(fd))
 }
+
+func readPasswordOptions(ctx context.Context, fd int, mask rune) ([]byte, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are read one at a time. With a context, a read returns after
+	// a tenth of a second without input, to check if it is done.
+	newState := *termios
+	newState.Lflag &^= unix.ECHO | unix.ICANON
+	newState.Lflag |= unix.ISIG
+	newState.Iflag |= unix.ICRNL
+	newState.Cc[unix.VMIN] = 1
+	newState.Cc[unix.VTIME] = 0
+	if ctx != nil {
+		newState.Cc[unix.VMIN] = 0
+		newState.Cc[unix.VTIME] = 1
+	}
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &newState); err != nil {
+		return nil, err
+	}
+
+	defer unix.IoctlSetTermios(fd, ioctlWriteTermios, termios)
+
+	return readPasswordKeys(ctx, passwordReader(fd), passwordReader(fd), mask)
+}
@@ -5,6 +5,8 @@
 package term
 
 import (
+	"context"
+	"io"
 	"os"
 
 	"golang.org/x/sys/windows"
@@ -52,6 +54,19 @@ func getSize(fd int) (width, height int, err error) {
 	return int(info.Window.Right - info.Window.Left + 1), int(info.Window.Bottom - info.Window.Top + 1), nil
 }
 
+func setSignals(fd int, on bool) error {
+	var st uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &st); err != nil {
+		return err
+	}
+	if on {
+		st |= windows.ENABLE_PROCESSED_INPUT
+	} else {
+		st &^= windows.ENABLE_PROCESSED_INPUT
+	}
+	return windows.SetConsoleMode(windows.Handle(fd), st)
+}
+
 func readPassword(fd int) ([]byte, error) {
 	var st uint32
 	if err := windows.GetConsoleMode(windows.Handle(fd), &st); err != nil {
@@ -77,3 +92,51 @@ func readPassword(fd int) ([]byte, error) {
 	defer f.Close()
 	return readPasswordLine(f)
 }
+
+// waitingReader reads from a console, returning no input after waiting a
+// tenth of a second for it, so a context can end the wait.
+type waitingReader struct {
+	h windows.Handle
+	f *os.File
+}
+
+func (r waitingReader) Read(buf []byte) (int, error) {
+	event, err := windows.WaitForSingleObject(r.h, 100)
+	if err != nil {
+		return 0, err
+	}
+	if event == uint32(windows.WAIT_TIMEOUT) {
+		return 0, nil
+	}
+	return r.f.Read(buf)
+}
+
+func readPasswordOptions(ctx context.Context, fd int, mask rune) ([]byte, error) {
+	var st uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &st); err != nil {
+		return nil, err
+	}
+	old := st
+
+	st &^= (windows.ENABLE_ECHO_INPUT | windows.ENABLE_LINE_INPUT)
+	st |= (windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_PROCESSED_INPUT)
+	if err := windows.SetConsoleMode(windows.Handle(fd), st); err != nil {
+		return nil, err
+	}
+
+	defer windows.SetConsoleMode(windows.Handle(fd), old)
+
+	var h windows.Handle
+	p, _ := windows.GetCurrentProcess()
+	if err := windows.DuplicateHandle(p, windows.Handle(fd), p, &h, 0, false, windows.DUPLICATE_SAME_ACCESS); err != nil {
+		return nil, err
+	}
+
+	f := os.NewFile(uintptr(h), "stdin")
+	defer f.Close()
+	var r io.Reader = f
+	if ctx != nil {
+		r = waitingReader{h, f}
+	}
+	return readPasswordKeys(ctx, r, os.Stdout, mask)
+}
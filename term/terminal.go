@@ -6,9 +6,11 @@ package term
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"unicode/utf8"
 )
@@ -46,6 +48,52 @@ type Terminal struct {
 	// and the new cursor position.
 	AutoCompleteCallback func(line string, pos int, key rune) (newLine string, newPos int, ok bool)
 
+	// ContinuationCallback, if non-null, is called with the input entered
+	// so far when Enter is pressed. If it returns true the input is
+	// incomplete: a newline is inserted and editing continues on the next
+	// line, which starts with the continuation prompt. Newlines in a
+	// bracketed paste are then also kept in the input instead of ending
+	// it.
+	ContinuationCallback func(input string) (more bool)
+
+	// Highlighter, if non-null, is called with the input line whenever it
+	// is written and returns the segments to write it in. The text of the
+	// segments must add up to the line, otherwise it is written without
+	// styles. The whole line is redrawn on each change so that earlier
+	// text can change style. It is called with the terminal locked and
+	// must not call methods of the Terminal.
+	Highlighter func(line string) []Segment
+
+	// Hinter, if non-null, is called after each key press with the input
+	// line when the cursor is at its end and history hints found no
+	// match. The returned hint is shown greyed out after the cursor and
+	// Right or End accepts it. It is called with the terminal locked and
+	// must not call methods of the Terminal.
+	Hinter func(line string) (hint string)
+
+	// CompleteCallback, if non-null, is called when Tab is pressed with
+	// the input line and the position of the cursor (in bytes, as an
+	// index into |line|). It returns the position where the text being
+	// completed starts, which is replaced by the chosen candidate. A
+	// single candidate is inserted directly; several are shown in a menu
+	// below the line, cycled through with Tab and Shift-Tab. Escape
+	// cancels the menu and any other key closes it.
+	CompleteCallback func(line string, pos int) (start int, candidates []Completion)
+
+	// MouseCallback, if non-null, is called for each mouse event reported
+	// after mouse reporting was turned on with SetMouse. If it returns
+	// true the event is not processed further, otherwise a left click in
+	// the input line moves the cursor to the clicked position. The
+	// terminal is unlocked while the callback runs.
+	MouseCallback func(event MouseEvent) (handled bool)
+
+	// PasteConfirmCallback, if non-null, is called with a paste block
+	// that spans several lines before it is inserted, see SetPasteBlock.
+	// If it returns false the paste is discarded. The terminal is
+	// unlocked while the callback runs, so it may write to the terminal,
+	// which shows the output above the prompt.
+	PasteConfirmCallback func(text string) (ok bool)
+
 	// Escape contains a pointer to the escape codes for this terminal.
 	// It's always a valid pointer, although the escape codes themselves
 	// may be empty if the terminal doesn't support them.
@@ -57,6 +105,9 @@ type Terminal struct {
 
 	c      io.ReadWriter
 	prompt []rune
+	// continuationPrompt is written at the start of each line after the
+	// first of a multi-line input.
+	continuationPrompt []rune
 
 	// line is the current line being entered.
 	line []rune
@@ -64,9 +115,23 @@ type Terminal struct {
 	pos int
 	// echo is true if local echo is enabled
 	echo bool
+	// noHistory is true if lines read should not be added to the history.
+	noHistory bool
 	// pasteActive is true iff there is a bracketed paste operation in
 	// progress.
 	pasteActive bool
+	// pasteNewline is true if a newline was pasted but not yet inserted
+	// into the line. It is inserted when more text is pasted, or ends the
+	// input when it was the end of the paste.
+	pasteNewline bool
+	// pasteBlock is true if a paste is inserted as a single block. The
+	// paste is collected in pasteBuf, pasteSize is its size in bytes and
+	// pasteCR is true if it last had a carriage return.
+	pasteBlock   bool
+	pasteBuf     []rune
+	pasteSize    int
+	pasteCR      bool
+	maxPasteSize int
 
 	// cursorX contains the current X value of the cursor where the left
 	// edge is 0. cursorY contains the row number where the first row of
@@ -83,6 +148,26 @@ type Terminal struct {
 	// a read. It aliases into inBuf.
 	remainder []byte
 	inBuf     [256]byte
+	// readCtx, if not nil, ends waiting for input once it is done. A read
+	// that continues in the background then delivers its input on
+	// pendingRead.
+	readCtx     context.Context
+	pendingRead chan readResult
+	// discardRead is true if a prompt ended while pendingRead was waiting
+	// for input, so that input arriving before the next prompt is shown is
+	// discarded.
+	discardRead bool
+
+	// widget, if not nil, redraws a widget such as the option list of
+	// Select, which the cursor position above doesn't account for. The
+	// cursor is on the first row of the widget, which is erased and drawn
+	// again below output written meanwhile.
+	widget func()
+
+	// screen is the full screen view while it is shown. Output written
+	// meanwhile is kept in screenOutput.
+	screen       *Screen
+	screenOutput []byte
 
 	// history contains previously entered commands so that they can be
 	// accessed with the up and down keys.
@@ -94,6 +179,83 @@ type Terminal struct {
 	// the incomplete, initial line. That value is stored in
 	// historyPending.
 	historyPending string
+
+	// search, if not nil, contains the state of the active incremental
+	// history search.
+	search *historySearch
+	// lastSearch is the query of the previous history search.
+	lastSearch []rune
+
+	// killRing contains the killed texts, most recent last.
+	killRing [][]rune
+	// yankIndex is the kill ring entry inserted by the last yank and
+	// yankLen its length.
+	yankIndex, yankLen int
+	// lastCommand is the editor command run by the current key and
+	// prevCommand the one run by the previous key.
+	lastCommand, prevCommand int
+
+	// undo contains the states of the line before each change, most
+	// recent last, and redo the states undone since the last change.
+	undo, redo []editState
+	// undoLine and undoPos are the state of the line after the last key
+	// press, which is pushed onto undo when the next key changes the line.
+	undoLine []rune
+	undoPos  int
+
+	// historyHints is true if the most recent history entry starting with
+	// the input line is shown as a hint.
+	historyHints bool
+	// hint is the hint shown after the input line.
+	hint []rune
+
+	// menu, if not nil, contains the state of the completion menu shown
+	// below the input line.
+	menu *completionMenu
+
+	// progress contains the progress lines shown above the prompt, in the
+	// order they were started. progressRows is the number of rows they
+	// take on the screen.
+	progress     []*Progress
+	progressRows int
+	// status is the text shown on the bottom row of the screen, or empty.
+	status []rune
+
+	// editMode selects the emacs or vi key bindings.
+	editMode EditMode
+	// viNormal is true while in vi normal (command) mode.
+	viNormal bool
+	// viPending is the vi operator waiting for a motion, or zero.
+	viPending rune
+	// viRegister contains the text deleted or yanked in vi normal mode.
+	viRegister []rune
+	// viInsertIndicator and viNormalIndicator are shown in front of the
+	// prompt in vi mode.
+	viInsertIndicator, viNormalIndicator []rune
+
+	// pager, if not nil, pages the output written to the terminal.
+	pager *pager
+	// pagingDisabled is true if the user turned paging off.
+	pagingDisabled bool
+
+	// bindings maps keys to the actions bound with BindKey.
+	bindings map[rune]KeyAction
+	// keymap, if not nil, maps keys to editor actions in emacs mode.
+	keymap *Keymap
+
+	// click is the click waiting for the cursor position report needed to
+	// find the clicked position in the input line.
+	click *MouseEvent
+
+	// caps contains the styles the terminal can display.
+	caps Capabilities
+	// asciiOnly is true if the terminal doesn't show UTF-8 text beyond
+	// ASCII.
+	asciiOnly bool
+
+	// lineInterrupt is true if Ctrl-C discards the line and ReadLine
+	// returns ErrInterrupted instead of io.EOF.
+	lineInterrupt bool
 }
 
 // NewTerminal runs a VT100 terminal on the given ReadWriter. If the ReadWriter is
@@ -109,13 +271,25 @@ func NewTerminal(c io.ReadWriter, prompt string) *Terminal {
 		termHeight:   24,
 		echo:         true,
 		historyIndex: -1,
+		caps:         Capabilities{Colors: Colors16},
+
+		continuationPrompt: []rune("... "),
+		viInsertIndicator:  []rune("(ins) "),
+		viNormalIndicator:  []rune("(cmd) "),
 	}
 }
 
 const (
 	keyCtrlC     = 3
 	keyCtrlD     = 4
+	keyCtrlG     = 7
+	keyTab       = 9
+	keyCtrlR     = 18
+	keyCtrlS     = 19
+	keyCtrlT     = 20
 	keyCtrlU     = 21
+	keyCtrlY     = 25
+	keyUndo      = 31 // ^_
 	keyEnter     = '\r'
 	keyEscape    = 27
 	keyBackspace = 127
@@ -133,6 +307,32 @@ const (
 	keyClearScreen
 	keyPasteStart
 	keyPasteEnd
+	keyAltD
+	keyAltY
+	keyAltU
+	keyAltL
+	keyAltC
+	keyRedo
+	keyBackTab
+	keyInsert
+	keyDelete
+	keyPageUp
+	keyPageDown
+	keyF1
+	keyF2
+	keyF3
+	keyF4
+	keyF5
+	keyF6
+	keyF7
+	keyF8
+	keyF9
+	keyF10
+	keyF11
+	keyF12
+	keyHistorySearchBackward
+	keyHistorySearchForward
+	keyMouse
 )
 
 var (
@@ -181,34 +381,39 @@ func bytesToKey(b []byte, pasteActive bool) (rune, []byte) {
 		return r, b[l:]
 	}
 
-	if !pasteActive && len(b) >= 3 && b[0] == keyEscape && b[1] == '[' {
-		switch b[2] {
-		case 'A':
-			return keyUp, b[3:]
-		case 'B':
-			return keyDown, b[3:]
-		case 'C':
-			return keyRight, b[3:]
-		case 'D':
-			return keyLeft, b[3:]
-		case 'H':
-			return keyHome, b[3:]
-		case 'F':
-			return keyEnd, b[3:]
+	if !pasteActive && len(b) >= 2 {
+		switch b[1] {
+		case 'b':
+			return keyAltLeft, b[2:]
+		case 'f':
+			return keyAltRight, b[2:]
+		case 'd':
+			return keyAltD, b[2:]
+		case 'y':
+			return keyAltY, b[2:]
+		case 'u':
+			return keyAltU, b[2:]
+		case 'l':
+			return keyAltL, b[2:]
+		case 'c':
+			return keyAltC, b[2:]
+		case '/':
+			return keyRedo, b[2:]
 		}
 	}
 
-	if !pasteActive && len(b) >= 6 && b[0] == keyEscape && b[1] == '[' && b[2] == '1' && b[3] == ';' && b[4] == '3' {
-		switch b[5] {
-		case 'C':
-			return keyAltRight, b[6:]
-		case 'D':
-			return keyAltLeft, b[6:]
-		}
+	if !pasteActive && len(b) >= 2 && isAltChar(b[1]) {
+		return keyAlt + rune(b[1]), b[2:]
+	}
+
+	if !pasteActive && len(b) >= 2 && b[1] != '[' && b[1] != 'O' {
+		// An escape that doesn't start a control sequence is the
+		// escape key itself.
+		return keyEscape, b[1:]
 	}
 
-	if !pasteActive && len(b) >= 6 && bytes.Equal(b[:6], pasteStart) {
-		return keyPasteStart, b[6:]
+	if !pasteActive && len(b) >= 2 {
+		return decodeEscape(b)
 	}
 
 	if pasteActive && len(b) >= 6 && bytes.Equal(b[:6], pasteEnd) {
@@ -248,9 +453,7 @@ func (t *Terminal) moveCursorToPos(pos int) {
 		return
 	}
 
-	x := visualLength(t.prompt) + pos
-	y := x / t.termWidth
-	x = x % t.termWidth
+	x, y := t.screenPos(pos)
 
 	up := 0
 	if y < t.cursorY {
@@ -326,15 +529,22 @@ func (t *Terminal) clearLineToRight() {
 const maxLineLength = 4096
 
 func (t *Terminal) setLine(newLine []rune, newPos int) {
+	if isMultiLine(newLine) || isMultiLine(t.line) {
+		t.line = newLine
+		t.pos = newPos
+		t.repaint()
+		return
+	}
+	endX, endY := t.screenPos(len(t.line))
+	t.line = newLine
 	if t.echo {
 		t.moveCursorToPos(0)
-		t.writeLine(newLine)
-		for i := len(newLine); i < len(t.line); i++ {
+		t.writeInput(0)
+		for i := t.cellsBetween(t.cursorX, t.cursorY, endX, endY); i > 0; i-- {
 			t.writeLine(space)
 		}
 		t.moveCursorToPos(newPos)
 	}
-	t.line = newLine
 	t.pos = newPos
 }
 
@@ -372,14 +582,21 @@ func (t *Terminal) eraseNPreviousChars(n int) {
 	t.pos -= n
 	t.moveCursorToPos(t.pos)
 
+	multiLine := isMultiLine(t.line)
+	endX, endY := t.screenPos(len(t.line))
 	copy(t.line[t.pos:], t.line[n+t.pos:])
 	t.line = t.line[:len(t.line)-n]
+	if multiLine {
+		t.repaint()
+		return
+	}
 	if t.echo {
-		t.writeLine(t.line[t.pos:])
-		for i := 0; i < n; i++ {
+		t.writeInput(t.pos)
+		cells := t.cellsBetween(t.cursorX, t.cursorY, endX, endY)
+		for i := 0; i < cells; i++ {
 			t.queue(space)
 		}
-		t.advanceCursor(n)
+		t.advanceCursor(cells)
 		t.moveCursorToPos(t.pos)
 	}
 }
@@ -428,46 +645,111 @@ func (t *Terminal) countToRightWord() int {
 	return pos - t.pos
 }
 
-// visualLength returns the number of visible glyphs in s.
+// visualLength returns the number of columns taken by the visible glyphs in
+// s.
 func visualLength(runes []rune) int {
-	inEscapeSeq := false
 	length := 0
-
-	for _, r := range runes {
-		switch {
-		case inEscapeSeq:
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-				inEscapeSeq = false
-			}
-		case r == '\x1b':
-			inEscapeSeq = true
-		default:
-			length++
-		}
+	for len(runes) > 0 {
+		n, width := nextGlyph(runes)
+		length += width
+		runes = runes[n:]
 	}
-
 	return length
 }
 
 // handleKey processes the given key and, optionally, returns a line of text
 // that the user has entered.
 func (t *Terminal) handleKey(key rune) (line string, ok bool) {
+	return t.handleTypedKey(key, key)
+}
+
+// handleTypedKey is handleKey for a key typed as typed, the key looked up
+// by keymapKey.
+func (t *Terminal) handleTypedKey(key, typed rune) (line string, ok bool) {
+	defer t.recordUndo()
+	defer func() {
+		if !ok {
+			t.updateHint()
+		}
+	}()
+
+	if t.pasteActive && t.ContinuationCallback != nil {
+		if t.pasteNewline {
+			t.pasteNewline = false
+			t.addKeyToLine('\n')
+		}
+		if key == keyEnter {
+			t.pasteNewline = true
+		} else {
+			t.addKeyToLine(key)
+		}
+		t.prevCommand, t.lastCommand = t.lastCommand, commandInsert
+		return
+	}
 	if t.pasteActive && key != keyEnter {
 		t.addKeyToLine(key)
+		t.prevCommand, t.lastCommand = t.lastCommand, commandInsert
+		return
+	}
+
+	if (t.search != nil || t.menu != nil) && key >= keyAlt && key < keyAlt+utf8.RuneSelf {
+		// Escape ends the search or closes the menu, and a character
+		// that arrived right after it is processed on its own.
+		t.handleKey(keyEscape)
+		key -= keyAlt
+		typed = key
+	}
+
+	if t.search != nil && t.handleSearchKey(key) {
+		return
+	}
+
+	if t.menu != nil && t.handleMenuKey(key) {
+		return
+	}
+
+	if action, bound := t.bindings[key]; bound {
+		t.prevCommand, t.lastCommand = t.lastCommand, commandOther
+		t.runKeyAction(action)
+		return
+	}
+
+	t.prevCommand, t.lastCommand = t.lastCommand, commandOther
+
+	if t.keymap != nil && t.editMode == EmacsMode {
+		var bound bool
+		if key, bound = t.keymap.translate(typed, key); !bound {
+			return
+		}
+	}
+
+	if t.editMode == ViMode {
+		var handled bool
+		if key, handled = t.handleViKey(key); handled {
+			return
+		}
+	}
+
+	if key == keyTab && t.CompleteCallback != nil {
+		t.complete()
 		return
 	}
 
 	switch key {
+	case keyCtrlR:
+		t.startSearch(true)
+	case keyCtrlS:
+		t.startSearch(false)
 	case keyBackspace:
 		if t.pos == 0 {
 			return
 		}
-		t.eraseNPreviousChars(1)
-	case keyAltLeft:
+		t.eraseNPreviousChars(t.graphemeBefore(t.pos))
+	case keyAltLeft, keyCtrlLeft:
 		// move left by a word.
 		t.pos -= t.countToLeftWord()
 		t.moveCursorToPos(t.pos)
-	case keyAltRight:
+	case keyAltRight, keyCtrlRight:
 		// move right by a word.
 		t.pos += t.countToRightWord()
 		t.moveCursorToPos(t.pos)
@@ -475,27 +757,37 @@ func (t *Terminal) handleKey(key rune) (line string, ok bool) {
 		if t.pos == 0 {
 			return
 		}
-		t.pos--
+		t.pos -= t.graphemeBefore(t.pos)
 		t.moveCursorToPos(t.pos)
 	case keyRight:
 		if t.pos == len(t.line) {
+			t.acceptHint()
 			return
 		}
-		t.pos++
+		t.pos += t.graphemeAfter(t.pos)
 		t.moveCursorToPos(t.pos)
 	case keyHome:
-		if t.pos == 0 {
+		start := t.lineStart(t.pos)
+		if t.pos == start {
 			return
 		}
-		t.pos = 0
+		t.pos = start
 		t.moveCursorToPos(t.pos)
 	case keyEnd:
+		end := t.lineEnd(t.pos)
 		if t.pos == len(t.line) {
+			t.acceptHint()
+			return
+		}
+		if t.pos == end {
 			return
 		}
-		t.pos = len(t.line)
+		t.pos = end
 		t.moveCursorToPos(t.pos)
 	case keyUp:
+		if t.moveToPreviousLine() {
+			return
+		}
 		entry, ok := t.history.NthPreviousEntry(t.historyIndex + 1)
 		if !ok {
 			return "", false
@@ -507,6 +799,9 @@ func (t *Terminal) handleKey(key rune) (line string, ok bool) {
 		runes := []rune(entry)
 		t.setLine(runes, len(runes))
 	case keyDown:
+		if t.moveToNextLine() {
+			return
+		}
 		switch t.historyIndex {
 		case -1:
 			return
@@ -523,8 +818,22 @@ func (t *Terminal) handleKey(key rune) (line string, ok bool) {
 			}
 		}
 	case keyEnter:
+		if t.ContinuationCallback != nil {
+			input := string(t.line)
+
+			t.lock.Unlock()
+			more := t.ContinuationCallback(input)
+			t.lock.Lock()
+
+			if more {
+				t.addKeyToLine('\n')
+				return
+			}
+		}
+		t.clearHint()
 		t.moveCursorToPos(len(t.line))
 		t.queue([]rune("\r\n"))
+		t.moveProgressBelow()
 		line = string(t.line)
 		ok = true
 		t.line = t.line[:0]
@@ -534,32 +843,66 @@ func (t *Terminal) handleKey(key rune) (line string, ok bool) {
 		t.maxLine = 0
 	case keyDeleteWord:
 		// Delete zero or more spaces and then one or more characters.
-		t.eraseNPreviousChars(t.countToLeftWord())
+		t.killBackward(t.countToLeftWord())
+	case keyAltD:
+		// Delete zero or more spaces and then one or more characters
+		// after the cursor.
+		t.killForward(t.countToRightWordEnd())
 	case keyDeleteLine:
 		// Delete everything from the current cursor position to the
 		// end of line.
-		for i := t.pos; i < len(t.line); i++ {
+		if end := t.lineEnd(t.pos); end < len(t.line) {
+			t.killToLineEnd(end)
+			return
+		}
+		t.kill(t.line[t.pos:], false)
+		endX, endY := t.screenPos(len(t.line))
+		for i := t.cellsBetween(t.cursorX, t.cursorY, endX, endY); i > 0; i-- {
 			t.queue(space)
 			t.advanceCursor(1)
 		}
 		t.line = t.line[:t.pos]
 		t.moveCursorToPos(t.pos)
-	case keyCtrlD:
+	case keyCtrlD, keyDelete:
 		// Erase the character under the current position.
 		// The EOF case when the line is empty is handled in
 		// readLine().
 		if t.pos < len(t.line) {
-			t.pos++
-			t.eraseNPreviousChars(1)
+			n := t.graphemeAfter(t.pos)
+			t.pos += n
+			t.eraseNPreviousChars(n)
 		}
 	case keyCtrlU:
-		t.eraseNPreviousChars(t.pos)
+		t.killBackward(t.pos - t.lineStart(t.pos))
+	case keyCtrlY:
+		t.yank()
+	case keyAltY:
+		t.yankPop()
+	case keyCtrlT:
+		t.transposeChars()
+	case keyHistorySearchBackward:
+		t.searchHistoryPrefix(true)
+	case keyHistorySearchForward:
+		t.searchHistoryPrefix(false)
+	case keyUndo:
+		t.undoEdit()
+	case keyRedo:
+		t.redoEdit()
+	case keyAltU:
+		t.changeWordCase(caseUpper)
+	case keyAltL:
+		t.changeWordCase(caseLower)
+	case keyAltC:
+		t.changeWordCase(caseCapitalize)
 	case keyClearScreen:
 		// Erases the screen and moves the cursor to the home position.
 		t.queue([]rune("\x1b[2J\x1b[H"))
-		t.queue(t.prompt)
+		t.progressRows = 0
+		t.writeProgress()
+		t.queueStatus()
+		t.queue(t.displayPrompt())
 		t.cursorX, t.cursorY = 0, 0
-		t.advanceCursor(visualLength(t.prompt))
+		t.advanceCursor(visualLength(t.displayPrompt()))
 		t.setLine(t.line, t.pos)
 	default:
 		if t.AutoCompleteCallback != nil {
@@ -582,6 +925,7 @@ func (t *Terminal) handleKey(key rune) (line string, ok bool) {
 			return
 		}
 		t.addKeyToLine(key)
+		t.lastCommand = commandInsert
 	}
 	return
 }
@@ -597,8 +941,15 @@ func (t *Terminal) addKeyToLine(key rune) {
 	t.line = t.line[:len(t.line)+1]
 	copy(t.line[t.pos+1:], t.line[t.pos:])
 	t.line[t.pos] = key
+	if t.pos < len(t.line)-1 && isMultiLine(t.line) {
+		// Inserting into a multi-line input can move the following
+		// lines, so all of it is redrawn.
+		t.pos++
+		t.repaint()
+		return
+	}
 	if t.echo {
-		t.writeLine(t.line[t.pos:])
+		t.writeInput(t.pos)
 	}
 	t.pos++
 	t.moveCursorToPos(t.pos)
@@ -606,13 +957,27 @@ func (t *Terminal) addKeyToLine(key rune) {
 
 func (t *Terminal) writeLine(line []rune) {
 	for len(line) != 0 {
+		if line[0] == '\n' {
+			t.writeNewline()
+			line = line[1:]
+			continue
+		}
 		remainingOnLine := t.termWidth - t.cursorX
-		todo := len(line)
-		if todo > remainingOnLine {
-			todo = remainingOnLine
+		todo, width := fitGlyphs(line, remainingOnLine)
+		if todo == 0 {
+			if t.cursorX > 0 {
+				// A wide character doesn't fit on the rest of
+				// the row, so the row is padded and it starts
+				// on the next one.
+				t.queue([]rune(strings.Repeat(" ", remainingOnLine)))
+				t.advanceCursor(remainingOnLine)
+				continue
+			}
+			// It doesn't fit on a row at all.
+			todo, width = nextGlyph(line)
 		}
 		t.queue(line[:todo])
-		t.advanceCursor(visualLength(line[:todo]))
+		t.advanceCursor(width)
 		line = line[todo:]
 	}
 }
@@ -647,26 +1012,62 @@ func writeWithCRLF(w io.Writer, buf []byte) (n int, err error) {
 }
 
 func (t *Terminal) Write(buf []byte) (n int, err error) {
+	if p := t.activePager(); p != nil {
+		return p.Write(buf)
+	}
+	return t.write(buf)
+}
+
+func (t *Terminal) write(buf []byte) (n int, err error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if t.cursorX == 0 && t.cursorY == 0 {
+	return t.writeAbove(buf)
+}
+
+// writeAbove writes buf above the progress lines and the prompt, redrawing
+// them below it. t.lock must be held.
+func (t *Terminal) writeAbove(buf []byte) (n int, err error) {
+	if t.screen != nil {
+		t.screenOutput = append(t.screenOutput, buf...)
+		return len(buf), nil
+	}
+
+	promptShown := t.cursorX != 0 || t.cursorY != 0
+	if !promptShown && t.widget == nil && t.progressRows == 0 && len(t.progress) == 0 {
 		// This is the easy case: there's nothing on the screen that we
 		// have to move out of the way.
 		return writeWithCRLF(t.c, buf)
 	}
 
-	// We have a prompt and possibly user input on the screen. We
-	// have to clear it first.
-	t.move(0 /* up */, 0 /* down */, t.cursorX /* left */, 0 /* right */)
-	t.cursorX = 0
-	t.clearLineToRight()
-
-	for t.cursorY > 0 {
-		t.move(1 /* up */, 0, 0, 0)
-		t.cursorY--
+	if promptShown {
+		// We have a prompt and possibly user input on the screen. We
+		// have to clear it first.
+		if t.menu != nil {
+			// Output closes the completion menu.
+			t.menu = nil
+			t.eraseBelowInput()
+		}
+		if isMultiLine(t.line) {
+			// Start from the last line of the input so no line
+			// below the cursor is left behind.
+			t.moveCursorToPos(len(t.line))
+		}
+		t.move(0 /* up */, 0 /* down */, t.cursorX /* left */, 0 /* right */)
+		t.cursorX = 0
 		t.clearLineToRight()
+
+		for t.cursorY > 0 {
+			t.move(1 /* up */, 0, 0, 0)
+			t.cursorY--
+			t.clearLineToRight()
+		}
+	}
+	if t.widget != nil {
+		// The widget is drawn again below the output.
+		t.queue([]rune("\r\x1b[J"))
 	}
+	t.clearProgress()
 
 	if _, err = t.c.Write(t.outBuf); err != nil {
 		return
@@ -677,12 +1078,24 @@ func (t *Terminal) Write(buf []byte) (n int, err error) {
 		return
 	}
 
-	t.writeLine(t.prompt)
-	if t.echo {
-		t.writeLine(t.line)
+	if len(t.progress) > 0 && len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		// The progress lines start on a row of their own.
+		t.outBuf = append(t.outBuf, crlf...)
 	}
+	t.writeProgress()
 
-	t.moveCursorToPos(t.pos)
+	if promptShown {
+		t.writeLine(t.displayPrompt())
+		if t.echo {
+			t.writeInput(0)
+			t.writeHint()
+		}
+
+		t.moveCursorToPos(t.pos)
+	}
+	if t.widget != nil {
+		t.widget()
+	}
 
 	if _, err = t.c.Write(t.outBuf); err != nil {
 		return
@@ -691,20 +1104,45 @@ func (t *Terminal) Write(buf []byte) (n int, err error) {
 	return
 }
 
+// setRemainder keeps rest, the input that has not been processed yet, at the
+// start of t.inBuf for the next read.
+func (t *Terminal) setRemainder(rest []byte) {
+	if len(rest) > 0 {
+		n := copy(t.inBuf[:], rest)
+		t.remainder = t.inBuf[:n]
+	} else {
+		t.remainder = nil
+	}
+}
+
 // ReadPassword temporarily changes the prompt and reads a password, without
 // echo, from the terminal.
 func (t *Terminal) ReadPassword(prompt string) (line string, err error) {
+	password, err := t.ReadPasswordWithOptions(prompt, PasswordOptions{})
+	line = string(password)
+	wipe(password)
+	return
+}
+
+// Prompt temporarily changes the prompt and reads a line of input from the
+// terminal. The line is not added to the history.
+func (t *Terminal) Prompt(prompt string) (line string, err error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	return t.promptLine(prompt)
+}
+
+// promptLine is Prompt with t.lock held.
+func (t *Terminal) promptLine(prompt string) (line string, err error) {
 	oldPrompt := t.prompt
 	t.prompt = []rune(prompt)
-	t.echo = false
+	t.noHistory = true
 
 	line, err = t.readLine()
 
 	t.prompt = oldPrompt
-	t.echo = true
+	t.noHistory = false
 
 	return
 }
@@ -724,8 +1162,17 @@ func (t *Terminal) AddHistory(line string) {
 func (t *Terminal) readLine() (line string, err error) {
 	// t.lock must be held at this point
 
+	defer t.resetSearch()
+	defer t.resetMenu()
+	t.resetUndo()
+
+	if t.editMode == ViMode {
+		t.viNormal = false
+	}
+
 	if t.cursorX == 0 && t.cursorY == 0 {
-		t.writeLine(t.prompt)
+		t.discardLateInput()
+		t.writeLine(t.displayPrompt())
 		t.c.Write(t.outBuf)
 		t.outBuf = t.outBuf[:0]
 	}
@@ -737,9 +1184,16 @@ func (t *Terminal) readLine() (line string, err error) {
 		lineOk := false
 		for !lineOk {
 			var key rune
+			seq := rest
 			key, rest = bytesToKey(rest, t.pasteActive)
 			if key == utf8.RuneError {
-				break
+				if !t.loneEscapeIsKey(rest) {
+					break
+				}
+				key, rest = keyEscape, nil
+			}
+			if !t.pasteActive && t.handleReport(key, seq[:len(seq)-len(rest)]) {
+				continue
 			}
 			if !t.pasteActive {
 				if key == keyCtrlD {
@@ -748,7 +1202,11 @@ func (t *Terminal) readLine() (line string, err error) {
 					}
 				}
 				if key == keyCtrlC {
-					return "", io.EOF
+					if !t.lineInterrupt {
+						return "", io.EOF
+					}
+					t.interruptLine(rest)
+					return "", ErrInterrupted
 				}
 				if key == keyPasteStart {
 					t.pasteActive = true
@@ -759,23 +1217,36 @@ func (t *Terminal) readLine() (line string, err error) {
 				}
 			} else if key == keyPasteEnd {
 				t.pasteActive = false
+				if t.pasteBlock {
+					// PasteConfirmCallback may read input
+					// itself, so the unread input is put
+					// back first.
+					t.setRemainder(rest)
+					line, lineOk = t.endPaste()
+					rest = t.remainder
+					continue
+				}
+				if t.pasteNewline {
+					// The paste ended with a newline, which
+					// is handled like Enter.
+					t.pasteNewline = false
+					line, lineOk = t.handleKey(keyEnter)
+				}
 				continue
 			}
 			if !t.pasteActive {
 				lineIsPasted = false
+			} else if t.pasteBlock {
+				t.pasteKey(key)
+				continue
 			}
-			line, lineOk = t.handleKey(key)
-		}
-		if len(rest) > 0 {
-			n := copy(t.inBuf[:], rest)
-			t.remainder = t.inBuf[:n]
-		} else {
-			t.remainder = nil
+			line, lineOk = t.handleTypedKey(key, keymapKey(key, seq[:len(seq)-len(rest)]))
 		}
+		t.setRemainder(rest)
 		t.c.Write(t.outBuf)
 		t.outBuf = t.outBuf[:0]
 		if lineOk {
-			if t.echo {
+			if t.echo && !t.noHistory {
 				t.historyIndex = -1
 				t.history.Add(line)
 			}
@@ -790,9 +1261,7 @@ func (t *Terminal) readLine() (line string, err error) {
 		readBuf := t.inBuf[len(t.remainder):]
 		var n int
 
-		t.lock.Unlock()
-		n, err = t.c.Read(readBuf)
-		t.lock.Lock()
+		n, err = t.read(readBuf)
 
 		if err != nil {
 			return
@@ -802,6 +1271,65 @@ func (t *Terminal) readLine() (line string, err error) {
 	}
 }
 
+// readKey reads a single key press from the terminal.
+func (t *Terminal) readKey() (rune, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.nextKey()
+}
+
+// nextKey reads a single key press from the terminal. t.lock must be held; it
+// is released while waiting for input. An escape that arrives on its own is
+// the escape key, as a terminal sends a control sequence in a single write.
+func (t *Terminal) nextKey() (rune, error) {
+	for read := false; ; read = true {
+		key, rest := bytesToKey(t.remainder, false)
+		if key == utf8.RuneError && read && len(rest) == 1 && rest[0] == keyEscape {
+			key, rest = keyEscape, nil
+		}
+		if key != utf8.RuneError {
+			t.setRemainder(rest)
+			return key, nil
+		}
+
+		readBuf := t.inBuf[len(t.remainder):]
+
+		n, err := t.read(readBuf)
+
+		if err != nil {
+			return 0, err
+		}
+
+		t.remainder = t.inBuf[:n+len(t.remainder)]
+	}
+}
+
+// Size returns the width and height of the terminal as last set with SetSize.
+func (t *Terminal) Size() (width, height int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.termWidth, t.termHeight
+}
+
+// SupportsANSI returns true if escape sequences can be written to the
+// terminal, i.e. the escape codes are not empty.
+func (t *Terminal) SupportsANSI() bool {
+	return len(t.Escape.Reset) > 0
+}
+
+// loneEscapeIsKey returns true if an escape at the end of the input should be
+// handled as the escape key rather than waiting for the rest of a control
+// sequence. This is only done in modes where escape is meaningful, as a
+// terminal sends a control sequence in a single write.
+func (t *Terminal) loneEscapeIsKey(rest []byte) bool {
+	if t.pasteActive || len(rest) != 1 || rest[0] != keyEscape {
+		return false
+	}
+	return t.search != nil || t.menu != nil || (t.editMode == ViMode && !t.viNormal)
+}
+
 // SetPrompt sets the prompt to be used when reading subsequent lines.
 func (t *Terminal) SetPrompt(prompt string) {
 	t.lock.Lock()
@@ -810,6 +1338,15 @@ func (t *Terminal) SetPrompt(prompt string) {
 	t.prompt = []rune(prompt)
 }
 
+// SetContinuationPrompt sets the prompt written at the start of each line
+// after the first of a multi-line input. The default is "... ".
+func (t *Terminal) SetContinuationPrompt(prompt string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.continuationPrompt = []rune(prompt)
+}
+
 func (t *Terminal) clearAndRepaintLinePlusNPrevious(numPrevLines int) {
 	// Move cursor to column zero at the start of the line.
 	t.move(t.cursorY, 0, t.cursorX, 0)
@@ -825,9 +1362,9 @@ func (t *Terminal) clearAndRepaintLinePlusNPrevious(numPrevLines int) {
 	t.move(t.cursorY, 0, 0, 0)
 	t.cursorX, t.cursorY = 0, 0
 
-	t.queue(t.prompt)
-	t.advanceCursor(visualLength(t.prompt))
-	t.writeLine(t.line)
+	t.queue(t.displayPrompt())
+	t.advanceCursor(visualLength(t.displayPrompt()))
+	t.writeInput(0)
 	t.moveCursorToPos(t.pos)
 }
 
@@ -842,15 +1379,42 @@ func (t *Terminal) SetSize(width, height int) error {
 	oldWidth := t.termWidth
 	t.termWidth, t.termHeight = width, height
 
+	if t.screen != nil {
+		// The line editor is redrawn for the new size once the screen
+		// is closed.
+		t.screen.resize(width, height)
+		return nil
+	}
+
+	t.reflow(oldWidth)
+	if t.menu != nil {
+		t.drawMenu()
+	}
+	if len(t.status) > 0 {
+		// The bottom row moved, so the scrolling region is set again.
+		t.reserveStatusRow()
+		t.queueStatus()
+	}
+	if len(t.outBuf) == 0 {
+		return nil
+	}
+
+	_, err := t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+	return err
+}
+
+// reflow redraws the prompt and the input after the width of the terminal
+// changed from oldWidth.
+func (t *Terminal) reflow(oldWidth int) {
+	width := t.termWidth
 	switch {
 	case width == oldWidth:
 		// If the width didn't change then nothing else needs to be
 		// done.
-		return nil
 	case len(t.line) == 0 && t.cursorX == 0 && t.cursorY == 0:
 		// If there is nothing on current line and no prompt printed,
 		// just do nothing
-		return nil
 	case width < oldWidth:
 		// Some terminals (e.g. xterm) will truncate lines that were
 		// too long when shinking. Others, (e.g. gnome-terminal) will
@@ -878,10 +1442,6 @@ func (t *Terminal) SetSize(width, height int) error {
 		// we can move back to the beginning and repaint everything.
 		t.clearAndRepaintLinePlusNPrevious(t.maxLine)
 	}
-
-	_, err := t.c.Write(t.outBuf)
-	t.outBuf = t.outBuf[:0]
-	return err
 }
 
 type pasteIndicatorError struct{}
@@ -900,7 +1460,8 @@ var ErrPasteIndicator = pasteIndicatorError{}
 // with markers. Not all terminals support this but, if it is supported, then
 // enabling this mode will stop any autocomplete callback from running due to
 // pastes. Additionally, any lines that are completely pasted will be returned
-// from ReadLine with the error set to ErrPasteIndicator.
+// from ReadLine with the error set to ErrPasteIndicator. SetPasteBlock makes a
+// paste of several lines part of a single input.
 func (t *Terminal) SetBracketedPasteMode(on bool) {
 	if on {
 		io.WriteString(t.c, "\x1b[?2004h")
@@ -951,7 +1512,8 @@ func (s *stRingBuffer) NthPreviousEntry(n int) (value string, ok bool) {
 
 // readPasswordLine reads from reader until it finds \n or io.EOF.
 // The slice returned does not include the \n.
-// readPasswordLine also ignores any \r it finds.
+// readPasswordLine also ignores any \r it finds. Buffers that held the
+// password are zeroed when they are replaced.
 // Windows uses \r as end of line. So, on Windows, readPasswordLine
 // reads until it finds \r and ignores any \n it finds during processing.
 func readPasswordLine(reader io.Reader) ([]byte, error) {
@@ -964,6 +1526,7 @@ func readPasswordLine(reader io.Reader) ([]byte, error) {
 			switch buf[0] {
 			case '\b':
 				if len(ret) > 0 {
+					ret[len(ret)-1] = 0
 					ret = ret[:len(ret)-1]
 				}
 			case '\n':
@@ -977,7 +1540,7 @@ func readPasswordLine(reader io.Reader) ([]byte, error) {
 				}
 				// otherwise ignore \r
 			default:
-				ret = append(ret, buf[0])
+				ret = appendSecret(ret, buf[0])
 			}
 			continue
 		}
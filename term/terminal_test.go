@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -214,6 +215,174 @@ var keyPressTests = []struct {
 		line: "a",
 		err:  ErrPasteIndicator,
 	},
+	{
+		// Ctrl-R searches the history and Enter accepts the match.
+		in:             "foo\rbar\rbaz\r\022fo\r",
+		line:           "foo",
+		throwAwayLines: 3,
+	},
+	{
+		// Ctrl-R again finds the next older match.
+		in:             "foo\rfoo2\r\022foo\022\r",
+		line:           "foo",
+		throwAwayLines: 2,
+	},
+	{
+		// Ctrl-S searches forward again.
+		in:             "a1\ra2\ra3\r\022a\022\022\023\r",
+		line:           "a2",
+		throwAwayLines: 3,
+	},
+	{
+		// Esc leaves the match on the line with the cursor at the match.
+		in:             "hello\r\022ell\033X\r",
+		line:           "hXello",
+		throwAwayLines: 1,
+	},
+	{
+		// Other keys end the search and are processed normally.
+		in:             "hello\r\022ell\001X\r",
+		line:           "Xhello",
+		throwAwayLines: 1,
+	},
+	{
+		// Ctrl-G aborts the search and restores the line.
+		in:             "hello\rab\022hel\007\r",
+		line:           "ab",
+		throwAwayLines: 1,
+	},
+	{
+		// A failed search keeps the line.
+		in:             "hello\r\022xyz\r",
+		line:           "",
+		throwAwayLines: 1,
+	},
+	{
+		// Backspace removes a character from the query.
+		in:             "abc\rabd\r\022abc\177d\r",
+		line:           "abd",
+		throwAwayLines: 2,
+	},
+	{
+		// Ctrl-W kills a word and Ctrl-Y yanks it back.
+		in:   "hello world\027\031\031\r",
+		line: "hello worldworld",
+	},
+	{
+		// Ctrl-K kills to the end of the line.
+		in:   "abc def\001\006\006\006\006\013\001\031\r",
+		line: "defabc ",
+	},
+	{
+		// Consecutive kills are merged into one kill ring entry.
+		in:   "a b c\027\027\031\r",
+		line: "a b c",
+	},
+	{
+		// Ctrl-U kills to the beginning of the line.
+		in:   "abc\025x\031\r",
+		line: "xabc",
+	},
+	{
+		// Alt-Y rotates the kill ring after a yank.
+		in:   "one\027two\027\031\033y\r",
+		line: "one",
+	},
+	{
+		// Alt-Y without a yank does nothing.
+		in:   "one\027\033y\r",
+		line: "",
+	},
+	{
+		// Alt-B moves back a word.
+		in:   "one two\033bX\r",
+		line: "one Xtwo",
+	},
+	{
+		// Alt-F moves forward a word.
+		in:   "one two\001\033fX\r",
+		line: "one Xtwo",
+	},
+	{
+		// Alt-D kills the next word.
+		in:   "one two\001\033d\005\031\r",
+		line: " twoone",
+	},
+	{
+		// Ctrl-T at the end of the line swaps the last two characters.
+		in:   "ab\024\r",
+		line: "ba",
+	},
+	{
+		// Ctrl-T swaps the characters around the cursor.
+		in:   "abc\002\024X\r",
+		line: "acbX",
+	},
+	{
+		// Alt-U upcases the word.
+		in:   "hello world\001\033u\r",
+		line: "HELLO world",
+	},
+	{
+		// Alt-L downcases the word.
+		in:   "HELLO WORLD\001\033l\r",
+		line: "hello WORLD",
+	},
+	{
+		// Alt-C capitalizes words.
+		in:   "hELLO wORLD\001\033c\033c\r",
+		line: "Hello World",
+	},
+	{
+		// Ctrl-_ undoes consecutive inserts as a single change.
+		in:   "abc\037\r",
+		line: "",
+	},
+	{
+		// Moving the cursor starts a new change.
+		in:   "abc\x1b[Dx\037\r",
+		line: "abc",
+	},
+	{
+		// Ctrl-_ undoes a word deletion.
+		in:   "abc def\027\037\r",
+		line: "abc def",
+	},
+	{
+		// Alt-/ redoes the undone change.
+		in:   "abc def\027\037\037\033/\r",
+		line: "abc def",
+	},
+	{
+		// A new change discards the undone changes.
+		in:   "abc\037d\033/\r",
+		line: "d",
+	},
+	{
+		// Undo with nothing to undo does nothing.
+		in:   "\037a\037\037\r",
+		line: "",
+	},
+	{
+		// Delete erases the character under the cursor.
+		in:   "abc\x1b[D\x1b[D\x1b[3~\r",
+		line: "ac",
+	},
+	{
+		// Ctrl-Left and Ctrl-Right move by words.
+		in:   "one two\x1b[1;5D\x1b[1;5Dx\x1b[1;5Cy\r",
+		line: "xone ytwo",
+	},
+	{
+		// SS3 arrows as sent in application cursor mode.
+		in:   "ab\x1bODc\r",
+		line: "acb",
+	},
+	{
+		// Function and page keys without a binding are ignored.
+		in:   "a\x1b[15~\x1b[6~\x1bOQb\r",
+		line: "ab",
+	},
 	{
 		// Ctrl-C terminates readline
 		in:  "\003",
@@ -435,3 +604,473 @@ func TestOutputNewlines(t *testing.T) {
 		t.Errorf("incorrect output: was %q, expected %q", output, expected)
 	}
 }
+
+func TestReverseSearchPrompt(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("foo\r\022fo\033\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.ReadLine()
+	c.received = nil
+	line, _ := ss.ReadLine()
+	if line != "foo" {
+		t.Fatalf("line was %q, expected %q", line, "foo")
+	}
+	if !bytes.Contains(c.received, []byte("(reverse-i-search)`fo': foo")) {
+		t.Errorf("search prompt not rendered: %q", c.received)
+	}
+	if !bytes.HasSuffix(c.received, []byte("> foo\x1b[3D\x1b[3C\r\n")) {
+		t.Errorf("prompt not restored after search: %q", c.received)
+	}
+}
+
+var viKeyPressTests = []struct {
+	in   string
+	line string
+}{
+	{in: "abc\r", line: "abc"},
+	// Escape to normal mode, x deletes under the cursor.
+	{in: "abc\033x\r", line: "ab"},
+	{in: "abc\0330x\r", line: "bc"},
+	{in: "abc def\033bD\r", line: "abc "},
+	{in: "one two three\0330dw\r", line: "two three"},
+	{in: "one two three\0330wcwTWO\033\r", line: "one TWO three"},
+	{in: "one two\0330de\r", line: " two"},
+	{in: "one two\033dd\r", line: ""},
+	{in: "one two\033ccnew\r", line: "new"},
+	{in: "one two\0330ywP\r", line: "one one two"},
+	{in: "abc\0330xp\r", line: "bac"},
+	{in: "abc\033$ix\r", line: "abxc"},
+	{in: "abc\0330ax\r", line: "axbc"},
+	{in: "abc\0330Ax\r", line: "abcx"},
+	{in: "abc\033Ix\r", line: "xabc"},
+	{in: "abc\033hhx\r", line: "bc"},
+	{in: "abc\0330llx\r", line: "ab"},
+	{in: "abc\033xxu\r", line: "ab"},
+	{in: "abc\033xxuu\r", line: "abc"},
+	{in: "abc\0330Ixyz\033u\r", line: "abc"},
+	{in: "one\rtwo\033k\r", line: "one"},
+}
+
+func TestViMode(t *testing.T) {
+	for i, test := range viKeyPressTests {
+		for j := 1; j < len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			ss.SetEditMode(ViMode)
+			line, err := ss.ReadLine()
+			if strings.Count(test.in, "\r") > 1 {
+				line, err = ss.ReadLine()
+			}
+			if err != nil {
+				t.Errorf("Error resulting from vi test %d (%d bytes per read): %v", i, j, err)
+				break
+			}
+			if line != test.line {
+				t.Errorf("Line resulting from vi test %d (%d bytes per read) was '%s', expected '%s'", i, j, line, test.line)
+				break
+			}
+		}
+	}
+}
+
+func TestViModeIndicator(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\033\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.SetEditMode(ViMode)
+	ss.ReadLine()
+	if !bytes.HasPrefix(c.received, []byte("(ins) > ")) {
+		t.Errorf("insert indicator not shown: %q", c.received)
+	}
+	if !bytes.Contains(c.received, []byte("(cmd) > ab")) {
+		t.Errorf("normal indicator not shown: %q", c.received)
+	}
+}
+
+func continuesOnBackslash(input string) bool {
+	return strings.HasSuffix(input, "\\")
+}
+
+var multiLineTests = []struct {
+	in   string
+	line string
+	err  error
+}{
+	{in: "abc\r", line: "abc"},
+	{in: "abc\\\rdef\r", line: "abc\\\ndef"},
+	// Up and down move between the lines of the input.
+	{in: "abc\\\rdef\x1b[Ax\r", line: "abcx\\\ndef"},
+	{in: "abc\\\rdef\x1b[A\x1b[Bx\r", line: "abc\\\ndefx"},
+	{in: "abcdef\\\rg\x1b[A\x1b[Hx\x1b[B\x1b[Fy\r", line: "xabcdef\\\ngy"},
+	// Backspace at the start of a line joins it with the previous one.
+	{in: "abc\\\rdef\x01\177\r", line: "abc\\def"},
+	{in: "abc\\\rdef\x1b[A\x01\x0b\x0b\r", line: "def"},
+	// Newlines in a paste are kept in the input, and a paste ending in a
+	// newline ends the input.
+	{in: "\x1b[200~abc\rdef\x1b[201~\r", line: "abc\ndef"},
+	{in: "\x1b[200~abc\rdef\r\x1b[201~", line: "abc\ndef", err: ErrPasteIndicator},
+}
+
+func TestMultiLine(t *testing.T) {
+	for i, test := range multiLineTests {
+		for j := 1; j < len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			ss.ContinuationCallback = continuesOnBackslash
+			line, err := ss.ReadLine()
+			if line != test.line {
+				t.Errorf("Line resulting from multi-line test %d (%d bytes per read) was %q, expected %q", i, j, line, test.line)
+				break
+			}
+			if err != test.err {
+				t.Errorf("Error resulting from multi-line test %d (%d bytes per read) was '%v', expected '%v'", i, j, err, test.err)
+				break
+			}
+		}
+	}
+}
+
+func TestMultiLineRender(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\\\rc\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.ContinuationCallback = continuesOnBackslash
+	ss.SetContinuationPrompt(". ")
+	ss.ReadLine()
+	expected := "> ab\\\x1b[K\r\n. c\r\n"
+	if string(c.received) != expected {
+		t.Errorf("incorrect output: was %q, expected %q", c.received, expected)
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("ab\x1b[D\177\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.Highlighter = func(line string) []Segment {
+		if len(line) < 2 {
+			return []Segment{{Text: line}}
+		}
+		return []Segment{{Text: line[:1], Style: ss.Escape.Green}, {Text: line[1:]}}
+	}
+	line, _ := ss.ReadLine()
+	if line != "b" {
+		t.Fatalf("line was %q, expected %q", line, "b")
+	}
+	// Each change redraws the whole line so the first character changes
+	// style as the line grows and shrinks.
+	expected := "> a" +
+		"\x1b[D\x1b[32ma\x1b[0mb" +
+		"\x1b[D" +
+		"\x1b[D" + "b" + " " + "\x1b[2D" +
+		"\x1b[C\r\n"
+	if string(c.received) != expected {
+		t.Errorf("incorrect output: was %q, expected %q", c.received, expected)
+	}
+}
+
+var hintTests = []struct {
+	in   string
+	line string
+}{
+	// Right and End accept the hint from the history.
+	{in: "hello world\rhe\x1b[C\r", line: "hello world"},
+	{in: "hello world\rhe\x05\r", line: "hello world"},
+	// The most recent matching entry is used.
+	{in: "hello world\rhelp\rhe\x1b[C\r", line: "help"},
+	{in: "hello world\rhelp\rhelx\x7fl\x1b[C\r", line: "hello world"},
+	// Enter doesn't accept the hint.
+	{in: "hello world\rhe\r", line: "he"},
+	// Without a matching entry the Hinter is asked.
+	{in: "hello world\rx\x1b[C\r", line: "x-hint"},
+}
+
+func TestHints(t *testing.T) {
+	for i, test := range hintTests {
+		for j := 1; j < len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			ss.SetHistoryHints(true)
+			ss.Hinter = func(line string) string {
+				return "-hint"
+			}
+			var line string
+			var err error
+			for k := strings.Count(test.in, "\r"); k > 0; k-- {
+				line, err = ss.ReadLine()
+			}
+			if err != nil {
+				t.Errorf("Error resulting from hint test %d (%d bytes per read): %v", i, j, err)
+				break
+			}
+			if line != test.line {
+				t.Errorf("Line resulting from hint test %d (%d bytes per read) was %q, expected %q", i, j, line, test.line)
+				break
+			}
+		}
+	}
+}
+
+func TestHintRender(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("abc\ra\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.SetHistoryHints(true)
+	ss.ReadLine()
+	c.received = nil
+	ss.ReadLine()
+	expected := "> a\x1b[90mbc\x1b[0m\x1b[2D" + "\x1b[J\r\n"
+	if string(c.received) != expected {
+		t.Errorf("incorrect output: was %q, expected %q", c.received, expected)
+	}
+}
+
+func completeWords(line string, pos int) (int, []Completion) {
+	start := strings.LastIndex(line[:pos], " ") + 1
+	var candidates []Completion
+	for _, w := range []string{"help", "hello", "exit"} {
+		if strings.HasPrefix(w, line[start:pos]) {
+			candidates = append(candidates, Completion{Text: w, Description: "the " + w + " command"})
+		}
+	}
+	return start, candidates
+}
+
+var completionTests = []struct {
+	in   string
+	line string
+	// whole is true if the input must be read at once, as an escape at
+	// the end of a read is the escape key while the menu is shown.
+	whole bool
+}{
+	// A single candidate is inserted.
+	{in: "e\t\r", line: "exit"},
+	{in: "x e\t\r", line: "x exit"},
+	// The prefix shared by the candidates is inserted.
+	{in: "h\t\r", line: "hel"},
+	// Tab and Shift-Tab cycle through the menu, Enter closes it.
+	{in: "hel\t\t\t\r\r", line: "hello"},
+	{in: "hel\t\t\t\t\r\r", line: "help"},
+	{in: "hel\t\x1b[Z\r\r", line: "hello", whole: true},
+	// Escape cancels the menu.
+	{in: "hel\t\t\x1b\r", line: "hel"},
+	// Other keys close the menu and are processed.
+	{in: "hel\t\tx\r", line: "helpx"},
+	{in: "\t\t\x1b[Dx\r", line: "helxp", whole: true},
+}
+
+func TestCompletion(t *testing.T) {
+	for i, test := range completionTests {
+		j := 1
+		if test.whole {
+			j = len(test.in)
+		}
+		for ; j <= len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			ss.CompleteCallback = completeWords
+			line, err := ss.ReadLine()
+			if err != nil {
+				t.Errorf("Error resulting from completion test %d (%d bytes per read): %v", i, j, err)
+				break
+			}
+			if line != test.line {
+				t.Errorf("Line resulting from completion test %d (%d bytes per read) was %q, expected %q", i, j, line, test.line)
+				break
+			}
+		}
+	}
+}
+
+func TestCompletionMenuRender(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("hel\t\t\r\r"),
+		bytesPerRead: 1,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.CompleteCallback = completeWords
+	ss.SetSize(60, 24)
+	ss.ReadLine()
+
+	menu := "\x1b[J\r\nhelp  (the help command)    hello  (the hello command)\r\x1b[A\x1b[5C"
+	if !bytes.Contains(c.received, []byte(menu)) {
+		t.Errorf("menu not rendered: %q", c.received)
+	}
+	selected := "\x1b[7mhelp  (the help command)\x1b[0m"
+	if !bytes.Contains(c.received, []byte(selected)) {
+		t.Errorf("selection not rendered: %q", c.received)
+	}
+	if !bytes.HasSuffix(c.received, []byte("\x1b[J\r\n")) {
+		t.Errorf("menu not erased: %q", c.received)
+	}
+}
+
+func TestCompletionMenuWideCandidates(t *testing.T) {
+	complete := func(line string, pos int) (int, []Completion) {
+		return 0, []Completion{{Text: "你好"}, {Text: "你好世界"}}
+	}
+	for _, test := range []struct {
+		width int
+		row   string
+	}{
+		// Cells are padded to the width of the widest candidate.
+		{width: 60, row: "\r\n\x1b[7m你好\x1b[0m      你好世界\r"},
+		// Candidates that don't fit are cut at a glyph boundary.
+		{width: 7, row: "\r\n\x1b[7m你好\x1b[0m\r\n你好\r"},
+	} {
+		c := &MockTerminal{
+			toSend:       []byte("\t\t\t\r\r"),
+			bytesPerRead: 1,
+		}
+		ss := NewTerminal(c, "> ")
+		ss.CompleteCallback = complete
+		ss.SetSize(test.width, 24)
+		ss.ReadLine()
+		if !bytes.Contains(c.received, []byte(test.row)) {
+			t.Errorf("menu at width %d not rendered: %q", test.width, c.received)
+		}
+	}
+}
+
+var wideKeyPressTests = []struct {
+	in   string
+	line string
+}{
+	{in: "你好\x1b[D\177\r", line: "好"},
+	{in: "你好\x1b[Dx\r", line: "你x好"},
+	// Combining marks are deleted and skipped with their base character.
+	{in: "ae\u0301\177\r", line: "a"},
+	{in: "ae\u0301x\x1b[D\x1b[D\004\r", line: "ax"},
+	{in: "ae\u0301x\x1b[D\x1b[Dy\r", line: "aye\u0301x"},
+	// Emoji sequences are a single character.
+	{in: "👋🏽x\x1b[D\x1b[D\004\r", line: "x"},
+}
+
+func TestWideKeyPresses(t *testing.T) {
+	for i, test := range wideKeyPressTests {
+		for j := 1; j < len(test.in); j++ {
+			c := &MockTerminal{
+				toSend:       []byte(test.in),
+				bytesPerRead: j,
+			}
+			ss := NewTerminal(c, "> ")
+			line, err := ss.ReadLine()
+			if err != nil {
+				t.Errorf("Error resulting from wide test %d (%d bytes per read): %v", i, j, err)
+				break
+			}
+			if line != test.line {
+				t.Errorf("Line resulting from wide test %d (%d bytes per read) was %q, expected %q", i, j, line, test.line)
+				break
+			}
+		}
+	}
+}
+
+var wideRenderTests = []struct {
+	in       string
+	width    int
+	received string
+}{
+	{
+		// Moving over a wide character moves two columns.
+		in:       "你好\x1b[D\r",
+		width:    80,
+		received: "> 你好\x1b[2D\x1b[2C\r\n",
+	},
+	{
+		// Combining marks take no column.
+		in:       "e\u0301\x1b[D\r",
+		width:    80,
+		received: "> e\u0301\x1b[D\x1b[C\r\n",
+	},
+	{
+		// A wide character that doesn't fit on the row starts on the
+		// next one.
+		in:       "ab你\r",
+		width:    5,
+		received: "> ab \r\n你\r\n",
+	},
+	{
+		// Deleting it erases the cells it took.
+		in:       "ab你\177\r",
+		width:    5,
+		received: "> ab \r\n你\x1b[A\x1b[2C   \x1b[A\x1b[2C\r\n",
+	},
+}
+
+func TestWideRender(t *testing.T) {
+	for i, test := range wideRenderTests {
+		c := &MockTerminal{
+			toSend:       []byte(test.in),
+			bytesPerRead: len(test.in),
+		}
+		ss := NewTerminal(c, "> ")
+		ss.SetSize(test.width, 24)
+		ss.ReadLine()
+		if string(c.received) != test.received {
+			t.Errorf("Results rendered from wide test %d was %q, expected %q", i, c.received, test.received)
+		}
+	}
+}
+
+func TestVisualLength(t *testing.T) {
+	tests := []struct {
+		in     string
+		length int
+	}{
+		{"abc", 3},
+		{"\x1b[32mabc\x1b[0m", 3},
+		{"你好", 4},
+		{"e\u0301", 1},
+		{"👋", 2},
+		{"👋🏽", 2},
+	}
+	for _, test := range tests {
+		if length := visualLength([]rune(test.in)); length != test.length {
+			t.Errorf("visualLength(%q) = %d, expected %d", test.in, length, test.length)
+		}
+	}
+}
+
+func TestLineInterrupt(t *testing.T) {
+	c := &MockTerminal{
+		toSend:       []byte("abc\003de\r"),
+		bytesPerRead: 100,
+	}
+	ss := NewTerminal(c, "> ")
+	ss.SetLineInterrupt(true)
+
+	if _, err := ss.ReadLine(); err != ErrInterrupted {
+		t.Fatalf("ReadLine returned %v, expected ErrInterrupted", err)
+	}
+	line, err := ss.ReadLine()
+	if err != nil || line != "de" {
+		t.Fatalf("ReadLine after interrupt returned %q, %v, expected \"de\"", line, err)
+	}
+	if got, expected := string(c.received), "> abc^C\r\n> de\r\n"; got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+}
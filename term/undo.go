@@ -0,0 +1,84 @@
+package term
+
+// maxUndoEntries is the number of changes to a line that can be undone.
+const maxUndoEntries = 100
+
+// editState is a snapshot of the line and cursor position.
+type editState struct {
+	line []rune
+	pos  int
+}
+
+// resetUndo clears the undo and redo history for a new line.
+func (t *Terminal) resetUndo() {
+	t.undo = nil
+	t.redo = nil
+	t.undoLine = append([]rune(nil), t.line...)
+	t.undoPos = t.pos
+}
+
+// recordUndo is called after each key press. If the key changed the line, the
+// line as it was before is saved so the change can be undone. Consecutive
+// inserts are saved as a single change, and changes made during a history
+// search are saved once the search ends.
+func (t *Terminal) recordUndo() {
+	if t.search != nil {
+		return
+	}
+	if runesEqual(t.line, t.undoLine) {
+		t.undoPos = t.pos
+		return
+	}
+
+	if t.lastCommand != commandInsert || t.prevCommand != commandInsert {
+		t.undo = append(t.undo, editState{line: t.undoLine, pos: t.undoPos})
+		if len(t.undo) > maxUndoEntries {
+			t.undo = t.undo[1:]
+		}
+	}
+	t.redo = nil
+	t.undoLine = append([]rune(nil), t.line...)
+	t.undoPos = t.pos
+}
+
+// undoEdit restores the line as it was before the last change.
+func (t *Terminal) undoEdit() {
+	t.lastCommand = commandUndo
+	if len(t.undo) == 0 {
+		return
+	}
+	t.redo = append(t.redo, editState{line: append([]rune(nil), t.line...), pos: t.pos})
+	state := t.undo[len(t.undo)-1]
+	t.undo = t.undo[:len(t.undo)-1]
+	t.restoreEdit(state)
+}
+
+// redoEdit reapplies the last change undone.
+func (t *Terminal) redoEdit() {
+	t.lastCommand = commandUndo
+	if len(t.redo) == 0 {
+		return
+	}
+	t.undo = append(t.undo, editState{line: append([]rune(nil), t.line...), pos: t.pos})
+	state := t.redo[len(t.redo)-1]
+	t.redo = t.redo[:len(t.redo)-1]
+	t.restoreEdit(state)
+}
+
+func (t *Terminal) restoreEdit(state editState) {
+	t.setLine(append([]rune(nil), state.line...), state.pos)
+	t.undoLine = state.line
+	t.undoPos = state.pos
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
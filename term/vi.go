@@ -0,0 +1,312 @@
+package term
+
+import "unicode/utf8"
+
+// EditMode selects the key bindings used to edit the input line.
+type EditMode int
+
+const (
+	// EmacsMode uses Emacs style key bindings. It is the default.
+	EmacsMode EditMode = iota
+	// ViMode uses vi style key bindings with an insert and a normal mode.
+	ViMode
+)
+
+func (m EditMode) String() string {
+	switch m {
+	case EmacsMode:
+		return "emacs"
+	case ViMode:
+		return "vi"
+	default:
+		return "unknown"
+	}
+}
+
+// SetEditMode switches the key bindings used to edit the input line. It can
+// be called at any time and takes effect with the next key.
+func (t *Terminal) SetEditMode(mode EditMode) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.editMode == mode {
+		return
+	}
+	t.editMode = mode
+	t.viNormal = false
+	t.viPending = 0
+}
+
+// EditMode returns the key bindings used to edit the input line.
+func (t *Terminal) EditMode() EditMode {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.editMode
+}
+
+// SetViModeIndicators sets the text shown in front of the prompt in vi
+// insert and normal mode. The defaults are "(ins) " and "(cmd) ".
+func (t *Terminal) SetViModeIndicators(insert, normal string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.viInsertIndicator = []rune(insert)
+	t.viNormalIndicator = []rune(normal)
+}
+
+// displayPrompt returns the prompt as shown on the screen, including the vi
+// mode indicator.
+func (t *Terminal) displayPrompt() []rune {
+	if t.editMode != ViMode || !t.echo {
+		return t.prompt
+	}
+	indicator := t.viInsertIndicator
+	if t.viNormal {
+		indicator = t.viNormalIndicator
+	}
+	if len(indicator) == 0 {
+		return t.prompt
+	}
+	return append(append([]rune(nil), indicator...), t.prompt...)
+}
+
+// altKeyLetters maps the keys decoded from escape followed by a letter back
+// to the letter, as in vi insert mode escape and a quickly typed letter may
+// arrive together.
+var altKeyLetters = map[rune]rune{
+	keyAltLeft:  'b',
+	keyAltRight: 'f',
+	keyAltD:     'd',
+	keyAltY:     'y',
+	keyAltU:     'u',
+	keyAltL:     'l',
+	keyAltC:     'c',
+}
+
+// altLetter returns the character typed after escape for a key decoded from
+// escape followed by a character.
+func altLetter(key rune) (rune, bool) {
+	if letter, ok := altKeyLetters[key]; ok {
+		return letter, true
+	}
+	if key >= keyAlt && key < keyAlt+utf8.RuneSelf && isAltChar(byte(key-keyAlt)) {
+		return key - keyAlt, true
+	}
+	return 0, false
+}
+
+// handleViKey processes key in vi mode. If handled is false the returned key
+// is processed by the default key bindings.
+func (t *Terminal) handleViKey(key rune) (rune, bool) {
+	if !t.viNormal {
+		letter, isAlt := altLetter(key)
+		if key != keyEscape && !isAlt {
+			return key, false
+		}
+		t.enterViNormal()
+		if isAlt {
+			return t.handleViKey(letter)
+		}
+		return key, true
+	}
+
+	switch key {
+	case keyEnter, keyUp, keyDown, keyCtrlR, keyCtrlS, keyClearScreen, keyUndo, keyRedo:
+		t.viPending = 0
+		return key, false
+	case 'k':
+		t.viPending = 0
+		return keyUp, false
+	case 'j':
+		t.viPending = 0
+		return keyDown, false
+	case keyEscape:
+		t.viPending = 0
+		return key, true
+	}
+
+	if t.viPending != 0 {
+		op := t.viPending
+		t.viPending = 0
+		if key == op {
+			// dd, cc and yy operate on the whole line.
+			t.viApply(op, 0, len(t.line))
+			return key, true
+		}
+		target, inclusive, ok := t.viMotion(key, op)
+		if !ok {
+			return key, true
+		}
+		start, end := t.pos, target
+		if end < start {
+			start, end = end, start
+		}
+		if inclusive && end < len(t.line) {
+			end++
+		}
+		t.viApply(op, start, end)
+		return key, true
+	}
+
+	switch key {
+	case 'i':
+		t.enterViInsert(t.pos)
+	case 'a':
+		t.enterViInsert(t.pos + 1)
+	case 'I':
+		t.enterViInsert(0)
+	case 'A':
+		t.enterViInsert(len(t.line))
+	case 'x':
+		if t.pos < len(t.line) {
+			t.viApply('d', t.pos, t.pos+1)
+		}
+	case 'D':
+		t.viApply('d', t.pos, len(t.line))
+	case 'C':
+		t.viApply('c', t.pos, len(t.line))
+	case 'd', 'c', 'y':
+		t.viPending = key
+	case 'p':
+		t.viPut(true)
+	case 'P':
+		t.viPut(false)
+	case 'u':
+		t.undoEdit()
+		t.viMoveTo(t.pos)
+	default:
+		if target, _, ok := t.viMotion(key, 0); ok {
+			t.viMoveTo(target)
+		}
+	}
+	return key, true
+}
+
+// viMotion returns the position the cursor would move to for the motion key.
+// inclusive is true if an operator applied to the motion includes the
+// character at the target.
+func (t *Terminal) viMotion(key rune, op rune) (target int, inclusive bool, ok bool) {
+	switch key {
+	case 'h', keyLeft, keyBackspace:
+		if t.pos > 0 {
+			return t.pos - 1, false, true
+		}
+		return t.pos, false, true
+	case 'l', keyRight, ' ':
+		if t.pos < len(t.line) {
+			return t.pos + 1, false, true
+		}
+		return t.pos, false, true
+	case 'w':
+		if op == 'c' {
+			// cw changes to the end of the word, like ce.
+			return t.viWordEnd(), true, true
+		}
+		return t.pos + t.countToRightWord(), false, true
+	case 'b', keyAltLeft:
+		return t.pos - t.countToLeftWord(), false, true
+	case 'e':
+		return t.viWordEnd(), true, true
+	case '0', keyHome:
+		return 0, false, true
+	case '$', keyEnd:
+		return len(t.line), false, true
+	}
+	return 0, false, false
+}
+
+// viWordEnd returns the position of the last character of the current or
+// next word.
+func (t *Terminal) viWordEnd() int {
+	pos := t.pos
+	if pos < len(t.line)-1 && t.line[pos+1] == ' ' {
+		pos++
+	}
+	for pos < len(t.line)-1 && t.line[pos] == ' ' {
+		pos++
+	}
+	for pos < len(t.line)-1 && t.line[pos+1] != ' ' {
+		pos++
+	}
+	return pos
+}
+
+// viMoveTo moves the cursor in normal mode, where it always rests on a
+// character of the line.
+func (t *Terminal) viMoveTo(pos int) {
+	if pos > len(t.line)-1 {
+		pos = len(t.line) - 1
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	t.pos = pos
+	t.moveCursorToPos(t.pos)
+}
+
+// viApply applies the operator op to the characters from start to end.
+func (t *Terminal) viApply(op rune, start, end int) {
+	t.viRegister = append([]rune(nil), t.line[start:end]...)
+	if op == 'y' {
+		t.viMoveTo(start)
+		return
+	}
+
+	newLine := append([]rune(nil), t.line[:start]...)
+	newLine = append(newLine, t.line[end:]...)
+	t.setLine(newLine, start)
+	if op == 'c' {
+		t.viNormal = false
+		t.repaintPromptLocked()
+		return
+	}
+	t.viMoveTo(start)
+}
+
+// viPut inserts the register after or before the cursor.
+func (t *Terminal) viPut(after bool) {
+	if len(t.viRegister) == 0 {
+		return
+	}
+	pos := t.pos
+	if after && pos < len(t.line) {
+		pos++
+	}
+	newLine := append([]rune(nil), t.line[:pos]...)
+	newLine = append(newLine, t.viRegister...)
+	newLine = append(newLine, t.line[pos:]...)
+	t.setLine(newLine, pos)
+	t.viMoveTo(pos + len(t.viRegister) - 1)
+}
+
+// enterViInsert switches to insert mode with the cursor at pos.
+func (t *Terminal) enterViInsert(pos int) {
+	if pos > len(t.line) {
+		pos = len(t.line)
+	}
+	t.viNormal = false
+	t.pos = pos
+	t.repaintPromptLocked()
+}
+
+// enterViNormal switches to normal mode, moving the cursor back onto the
+// last character like vi does.
+func (t *Terminal) enterViNormal() {
+	t.viNormal = true
+	t.viPending = 0
+	if t.pos > 0 {
+		t.pos--
+	}
+	t.repaintPromptLocked()
+}
+
+// repaintPromptLocked redraws the prompt and line to update the mode
+// indicator. The output is sent with the rest of the key's output.
+func (t *Terminal) repaintPromptLocked() {
+	if !t.echo {
+		t.moveCursorToPos(t.pos)
+		return
+	}
+	t.clearAndRepaintLinePlusNPrevious(t.maxLine)
+}
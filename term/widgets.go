@@ -0,0 +1,381 @@
+package term
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrCanceled is returned by the widgets when the user cancels the question
+// with Escape, Ctrl-C, Ctrl-G or Ctrl-D.
+var ErrCanceled = errors.New("term: canceled")
+
+// maxSelectRows is the maximum number of options shown at once by Select and
+// MultiSelect.
+const maxSelectRows = 10
+
+var crlfRunes = []rune{'\r', '\n'}
+
+const (
+	keyCtrlN = 14
+	keyCtrlP = 16
+)
+
+// isCancelKey returns true if key cancels a widget.
+func isCancelKey(key rune) bool {
+	return key == keyEscape || key == keyCtrlC || key == keyCtrlG || key == keyCtrlD
+}
+
+// selectList is the state of Select and MultiSelect.
+type selectList struct {
+	prompt  string
+	options []string
+	multi   bool
+
+	filter []rune
+	// matches are the indexes of the options that match the filter.
+	matches []int
+	// cursor is the highlighted entry of matches and top the first one
+	// shown.
+	cursor, top int
+	chosen      []bool
+}
+
+// update recomputes the options matching the filter, which is matched case
+// insensitively anywhere in the option.
+func (s *selectList) update() {
+	filter := strings.ToLower(string(s.filter))
+	s.matches = s.matches[:0]
+	for i, option := range s.options {
+		if strings.Contains(strings.ToLower(option), filter) {
+			s.matches = append(s.matches, i)
+		}
+	}
+	s.cursor, s.top = 0, 0
+}
+
+// moveCursor moves the highlight by n entries, keeping it in the window of
+// rows entries shown.
+func (s *selectList) moveCursor(n, rows int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.cursor = min(max(s.cursor+n, 0), len(s.matches)-1)
+	if s.cursor < s.top {
+		s.top = s.cursor
+	}
+	if s.cursor >= s.top+rows {
+		s.top = s.cursor - rows + 1
+	}
+}
+
+// answer returns the chosen options, as shown once the question is answered.
+func (s *selectList) answer() string {
+	if !s.multi {
+		return s.options[s.matches[s.cursor]]
+	}
+	var chosen []string
+	for i, ok := range s.chosen {
+		if ok {
+			chosen = append(chosen, s.options[i])
+		}
+	}
+	return strings.Join(chosen, ", ")
+}
+
+// selected returns the indexes of the chosen options.
+func (s *selectList) selected() []int {
+	if !s.multi {
+		return []int{s.matches[s.cursor]}
+	}
+	selected := []int{}
+	for i, ok := range s.chosen {
+		if ok {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// Select asks the user to pick one of options and returns its index. Typing
+// filters the options, the arrow keys move the highlight and Enter picks the
+// highlighted option. On terminals without escape codes the options are
+// listed with numbers and the number of the option is read instead.
+func (t *Terminal) Select(prompt string, options []string) (int, error) {
+	selected, err := t.selectOptions(prompt, options, false)
+	if err != nil {
+		return -1, err
+	}
+	return selected[0], nil
+}
+
+// MultiSelect asks the user to pick any number of options and returns their
+// indexes in order. It works like Select, with Space toggling the highlighted
+// option and Enter accepting the options toggled on.
+func (t *Terminal) MultiSelect(prompt string, options []string) ([]int, error) {
+	return t.selectOptions(prompt, options, true)
+}
+
+func (t *Terminal) selectOptions(prompt string, options []string, multi bool) ([]int, error) {
+	if len(options) == 0 {
+		return nil, errors.New("term: no options to select from")
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := &selectList{
+		prompt:  prompt,
+		options: options,
+		multi:   multi,
+		chosen:  make([]bool, len(options)),
+	}
+	if !t.SupportsANSI() {
+		return t.selectNumbered(s)
+	}
+	s.update()
+	t.widget = func() { t.drawSelect(s) }
+	defer func() { t.widget = nil }()
+
+	for {
+		rows := t.drawSelect(s)
+		key, err := t.nextKey()
+		if err != nil {
+			t.finishWidget(s.prompt, "")
+			return nil, err
+		}
+
+		switch key {
+		case keyUp, keyCtrlP:
+			s.moveCursor(-1, rows)
+		case keyDown, keyCtrlN:
+			s.moveCursor(1, rows)
+		case keyPageUp:
+			s.moveCursor(-rows, rows)
+		case keyPageDown:
+			s.moveCursor(rows, rows)
+		case keyHome:
+			s.moveCursor(-len(s.matches), rows)
+		case keyEnd:
+			s.moveCursor(len(s.matches), rows)
+		case keyBackspace:
+			if len(s.filter) > 0 {
+				s.filter = s.filter[:len(s.filter)-1]
+				s.update()
+			}
+		case keyCtrlU:
+			s.filter = s.filter[:0]
+			s.update()
+		case keyEnter:
+			if !multi && len(s.matches) == 0 {
+				continue
+			}
+			t.finishWidget(s.prompt, s.answer())
+			return s.selected(), nil
+		default:
+			switch {
+			case isCancelKey(key):
+				t.finishWidget(s.prompt, "")
+				return nil, ErrCanceled
+			case key == ' ' && multi:
+				if len(s.matches) > 0 {
+					i := s.matches[s.cursor]
+					s.chosen[i] = !s.chosen[i]
+				}
+			case isPrintable(key) && key < keyUnknown:
+				s.filter = append(s.filter, key)
+				s.update()
+			}
+		}
+	}
+}
+
+// drawSelect draws the question and the window of matching options below it,
+// leaving the cursor after the filter on the question row. It returns the
+// number of rows in the window. t.lock must be held.
+func (t *Terminal) drawSelect(s *selectList) int {
+	rows := min(maxSelectRows, max(t.screenHeight()-2, 1))
+	s.moveCursor(0, rows)
+
+	header := []rune("? " + s.prompt + " " + string(s.filter))
+	n, width := fitGlyphs(header, t.termWidth-1)
+	t.queue([]rune("\r\x1b[J"))
+	t.queue(header[:n])
+
+	shown := 0
+	if len(s.matches) == 0 {
+		t.queue([]rune("\r\n  (no matches)"))
+		shown = 1
+	}
+	for i := s.top; i < len(s.matches) && i < s.top+rows; i++ {
+		line := "  "
+		if i == s.cursor {
+			line = "> "
+		}
+		if s.multi {
+			if s.chosen[s.matches[i]] {
+				line += "[x] "
+			} else {
+				line += "[ ] "
+			}
+		}
+		entry := []rune(line + s.options[s.matches[i]])
+		n, _ := fitGlyphs(entry, t.termWidth-1)
+		t.queue(crlfRunes)
+		t.queue(entry[:n])
+		shown++
+	}
+
+	t.queue([]rune{'\r'})
+	t.move(shown, 0, 0, width)
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+	return rows
+}
+
+// finishWidget replaces a widget with the question and the answer given.
+// t.lock must be held.
+func (t *Terminal) finishWidget(prompt, answer string) {
+	if t.SupportsANSI() {
+		t.queue([]rune("\r\x1b[J"))
+	}
+	t.queue([]rune("? " + prompt + " " + answer))
+	t.queue(crlfRunes)
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+}
+
+// selectNumbered lists the options with numbers and reads the numbers of the
+// chosen ones. t.lock must be held.
+func (t *Terminal) selectNumbered(s *selectList) ([]int, error) {
+	t.queue([]rune("? " + s.prompt))
+	t.queue(crlfRunes)
+	for i, option := range s.options {
+		t.queue([]rune("  " + strconv.Itoa(i+1) + ") " + option))
+		t.queue(crlfRunes)
+	}
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+
+	prompt := "Enter a number: "
+	if s.multi {
+		prompt = "Enter numbers separated by spaces: "
+	}
+	for {
+		line, err := t.promptLine(prompt)
+		if err != nil {
+			return nil, err
+		}
+		if selected, ok := parseNumbers(line, len(s.options), s.multi); ok {
+			return selected, nil
+		}
+		t.writeAbove([]byte("Please enter a number between 1 and " + strconv.Itoa(len(s.options)) + ".\n"))
+	}
+}
+
+// parseNumbers parses the option numbers in line, starting with 1, and
+// returns them as indexes.
+func parseNumbers(line string, count int, multi bool) ([]int, bool) {
+	fields := strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 && multi {
+		return []int{}, true
+	}
+	if len(fields) == 0 || (len(fields) > 1 && !multi) {
+		return nil, false
+	}
+	selected := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > count {
+			return nil, false
+		}
+		selected = append(selected, n-1)
+	}
+	return selected, true
+}
+
+// Confirm asks a yes or no question, answered with a single key press of y or
+// n. Enter gives the default answer.
+func (t *Terminal) Confirm(prompt string, defaultYes bool) (bool, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	choices := "(y/N)"
+	if defaultYes {
+		choices = "(Y/n)"
+	}
+	question := []rune("? " + prompt + " " + choices + " ")
+	t.queue(question)
+	t.c.Write(t.outBuf)
+	t.outBuf = t.outBuf[:0]
+	if t.SupportsANSI() {
+		t.widget = func() { t.queue(question) }
+		defer func() { t.widget = nil }()
+	}
+
+	for {
+		key, err := t.nextKey()
+		if err != nil {
+			t.finishWidget(prompt, "")
+			return false, err
+		}
+
+		answer := defaultYes
+		switch key {
+		case 'y', 'Y':
+			answer = true
+		case 'n', 'N':
+			answer = false
+		case keyEnter:
+		default:
+			if isCancelKey(key) {
+				t.finishWidget(prompt, "")
+				return false, ErrCanceled
+			}
+			continue
+		}
+
+		if answer {
+			t.finishWidget(prompt, "yes")
+		} else {
+			t.finishWidget(prompt, "no")
+		}
+		return answer, nil
+	}
+}
+
+// Input asks for a line of text. An empty line gives defaultValue, which is
+// shown with the question if it is not empty. If validate is not nil, it is
+// called with the answer and the question is asked again, below the error it
+// returned, until it returns nil.
+func (t *Terminal) Input(prompt, defaultValue string, validate func(string) error) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	question := "? " + prompt + " "
+	if defaultValue != "" {
+		question += "(" + defaultValue + ") "
+	}
+	for {
+		line, err := t.promptLine(question)
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			line = defaultValue
+		}
+		if validate == nil {
+			return line, nil
+		}
+
+		err = validate(line)
+		if err == nil {
+			return line, nil
+		}
+		caps := t.caps
+		if !t.SupportsANSI() {
+			caps = Capabilities{}
+		}
+		t.writeAbove([]byte(caps.Render(Style{Fg: Red}, "! "+err.Error()) + "\n"))
+	}
+}
@@ -0,0 +1,272 @@
+package term
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var widgetOptions = []string{"apple", "banana", "cherry", "date"}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected int
+		err      error
+	}{
+		{"\r", 0, nil},
+		{"\x1b[B\x1b[B\r", 2, nil},
+		{"\x1b[B\x1b[B\x1b[A\r", 1, nil},
+		{"\x1b[F\r", 3, nil},
+		{"an\r", 1, nil},
+		{"Che\r", 2, nil},
+		{"xyz\r\x7f\x7f\x7fd\r", 3, nil},
+		{"\x1b", -1, ErrCanceled},
+		{"\x03", -1, ErrCanceled},
+	}
+	for i, test := range tests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ")
+		got, err := ss.Select("fruit?", widgetOptions)
+		if got != test.expected || err != test.err {
+			t.Errorf("#%d: got %d, %v, expected %d, %v", i, got, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestSelectOutput(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\x1b[B\r")}
+	ss := NewTerminal(c, "> ")
+	ss.SetSize(80, 24)
+	c.received = nil
+	if _, err := ss.Select("fruit?", widgetOptions[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\r\x1b[J? fruit? \r\n> apple\r\n  banana\r\x1b[2A\x1b[9C" +
+		"\r\x1b[J? fruit? \r\n  apple\r\n> banana\r\x1b[2A\x1b[9C" +
+		"\r\x1b[J? fruit? banana\r\n"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+}
+
+func TestSelectWindow(t *testing.T) {
+	options := make([]string, 20)
+	for i := range options {
+		options[i] = strings.Repeat("x", i+1)
+	}
+	c := &MockTerminal{toSend: []byte("\x1b[6~\x1b[6~\r")}
+	ss := NewTerminal(c, "> ")
+	ss.SetSize(80, 6)
+	c.received = nil
+	got, err := ss.Select("which?", options)
+	if got != 8 || err != nil {
+		t.Errorf("got %d, %v, expected 8", got, err)
+	}
+	if strings.Contains(string(c.received), "\x1b[5A") {
+		t.Errorf("more rows than fit on the screen were drawn: %q", c.received)
+	}
+}
+
+func TestMultiSelect(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected []int
+	}{
+		{"\r", []int{}},
+		{" \x1b[B\x1b[B \r", []int{0, 2}},
+		{"\x1b[B\x1b[B \x1b[A\x1b[A \r", []int{0, 2}},
+		{"  \r", []int{}},
+		{"da \x7f\x7f\x1b[B \r", []int{1, 3}},
+	}
+	for i, test := range tests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ")
+		got, err := ss.MultiSelect("fruits?", widgetOptions)
+		if err != nil || !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("#%d: got %v, %v, expected %v", i, got, err, test.expected)
+		}
+	}
+}
+
+func TestSelectNumbered(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("7\r2\r")}
+	ss := NewTerminal(c, "> ")
+	ss.SetCapabilities(Capabilities{})
+	got, err := ss.Select("fruit?", widgetOptions)
+	if got != 1 || err != nil {
+		t.Errorf("got %d, %v, expected 1", got, err)
+	}
+	if !strings.Contains(string(c.received), "  3) cherry\r\n") {
+		t.Errorf("options are not listed: %q", c.received)
+	}
+
+	c = &MockTerminal{toSend: []byte("4, 1\r")}
+	ss = NewTerminal(c, "> ")
+	ss.SetCapabilities(Capabilities{})
+	selected, err := ss.MultiSelect("fruits?", widgetOptions)
+	if err != nil || !reflect.DeepEqual(selected, []int{3, 0}) {
+		t.Errorf("got %v, %v, expected [3 0]", selected, err)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		in         string
+		defaultYes bool
+		expected   bool
+		err        error
+		output     string
+	}{
+		{"y", false, true, nil, "? sure? (y/N) \r\x1b[J? sure? yes\r\n"},
+		{"xN", true, false, nil, "? sure? (Y/n) \r\x1b[J? sure? no\r\n"},
+		{"\r", true, true, nil, "? sure? (Y/n) \r\x1b[J? sure? yes\r\n"},
+		{"\r", false, false, nil, "? sure? (y/N) \r\x1b[J? sure? no\r\n"},
+		{"\x07", true, false, ErrCanceled, "? sure? (Y/n) \r\x1b[J? sure? \r\n"},
+	}
+	for i, test := range tests {
+		c := &MockTerminal{toSend: []byte(test.in)}
+		ss := NewTerminal(c, "> ")
+		got, err := ss.Confirm("sure?", test.defaultYes)
+		if got != test.expected || err != test.err {
+			t.Errorf("#%d: got %t, %v, expected %t, %v", i, got, err, test.expected, test.err)
+		}
+		if string(c.received) != test.output {
+			t.Errorf("#%d: incorrect output: was %q, expected %q", i, c.received, test.output)
+		}
+	}
+}
+
+func TestInput(t *testing.T) {
+	c := &MockTerminal{toSend: []byte("\r")}
+	ss := NewTerminal(c, "> ")
+	got, err := ss.Input("name?", "bob", nil)
+	if got != "bob" || err != nil {
+		t.Errorf("got %q, %v, expected the default", got, err)
+	}
+	if !strings.HasPrefix(string(c.received), "? name? (bob) ") {
+		t.Errorf("default is not shown: %q", c.received)
+	}
+
+	errShort := errors.New("too short")
+	validate := func(s string) error {
+		if len(s) < 3 {
+			return errShort
+		}
+		return nil
+	}
+	c = &MockTerminal{toSend: []byte("al\ralice\r")}
+	ss = NewTerminal(c, "> ")
+	got, err = ss.Input("name?", "", validate)
+	if got != "alice" || err != nil {
+		t.Errorf("got %q, %v, expected alice", got, err)
+	}
+	if !strings.Contains(string(c.received), "\x1b[31m! too short\x1b[0m\r\n") {
+		t.Errorf("validation error is not shown: %q", c.received)
+	}
+
+	ss.AddHistory("x")
+	c.toSend = []byte("\x1b[A\r")
+	if got, _ := ss.Input("again?", "", nil); got != "x" {
+		t.Errorf("history is not available: got %q", got)
+	}
+}
+
+// syncTerminal is a MockTerminal that is written by another goroutine.
+type syncTerminal struct {
+	lock sync.Mutex
+	MockTerminal
+}
+
+func (c *syncTerminal) Write(data []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.MockTerminal.Write(data)
+}
+
+// waitFor waits until s has been written to the terminal and returns the
+// output.
+func (c *syncTerminal) waitFor(t *testing.T, s string) string {
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		c.lock.Lock()
+		received := string(c.received)
+		c.lock.Unlock()
+		if strings.Contains(received, s) {
+			return received
+		}
+	}
+	t.Fatalf("%q was not written", s)
+	return ""
+}
+
+func TestSpinner(t *testing.T) {
+	c := &syncTerminal{}
+	ss := NewTerminal(c, "> ")
+	ss.StartProgress("copying")
+	p := ss.StartSpinner("working")
+	if got, expected := c.waitFor(t, "working"), "copying 0\r\n"+"\x1b[A\x1b[J"+"copying 0\r\n| working\r\n"; got != expected {
+		t.Errorf("incorrect output on start: was %q, expected %q", got, expected)
+	}
+
+	// Each frame redraws only the row of the spinner.
+	c.lock.Lock()
+	c.received = nil
+	c.lock.Unlock()
+	if got, expected := c.waitFor(t, "\x1b8"), "\x1b7\x1b[A\r/ working\x1b[K\x1b8"; got != expected {
+		t.Errorf("incorrect output for a frame: was %q, expected %q", got, expected)
+	}
+
+	p.Done("done")
+	c.lock.Lock()
+	c.received = nil
+	c.lock.Unlock()
+	time.Sleep(2 * spinnerInterval)
+	ss.Printf("log")
+	if got, expected := c.waitFor(t, "log"), "\x1b[A\x1b[J"+"log\r\n"+"copying 0\r\n"; got != expected {
+		t.Errorf("spinner was not stopped: output was %q, expected %q", got, expected)
+	}
+}
+
+// interleavedTerminal is a MockTerminal that calls beforeRead before each
+// read, as if output were written while the terminal waits for input.
+type interleavedTerminal struct {
+	MockTerminal
+	beforeRead func()
+}
+
+func (c *interleavedTerminal) Read(data []byte) (int, error) {
+	if f := c.beforeRead; f != nil {
+		c.beforeRead = nil
+		f()
+	}
+	return c.MockTerminal.Read(data)
+}
+
+func TestWidgetOutputAbove(t *testing.T) {
+	c := &interleavedTerminal{MockTerminal: MockTerminal{toSend: []byte("\r")}}
+	ss := NewTerminal(c, "> ")
+	c.beforeRead = func() { ss.Printf("log") }
+	if _, err := ss.Select("fruit?", widgetOptions[:2]); err != nil {
+		t.Fatal(err)
+	}
+	list := "\r\x1b[J? fruit? \r\n> apple\r\n  banana\r\x1b[2A\x1b[9C"
+	expected := list + "\r\x1b[J" + "log\r\n" + list + "\r\x1b[J? fruit? apple\r\n"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+
+	c = &interleavedTerminal{MockTerminal: MockTerminal{toSend: []byte("y")}}
+	ss = NewTerminal(c, "> ")
+	c.beforeRead = func() { ss.Printf("log") }
+	if _, err := ss.Confirm("sure?", false); err != nil {
+		t.Fatal(err)
+	}
+	expected = "? sure? (y/N) " + "\r\x1b[J" + "log\r\n" + "? sure? (y/N) " + "\r\x1b[J? sure? yes\r\n"
+	if got := string(c.received); got != expected {
+		t.Errorf("incorrect output: was %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,183 @@
+package term
+
+import (
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// maxGraphemeRunes is the most runes looked at to find the end of a grapheme
+// cluster. Real clusters, even emoji sequences, are much shorter.
+const maxGraphemeRunes = 32
+
+// nextGlyph returns the number of runes at the start of runes making up the
+// next glyph, and its width in columns. An escape sequence is a glyph of
+// width zero. Otherwise a glyph is a grapheme cluster, such as a letter with
+// its combining marks or an emoji sequence, which is two columns wide if it
+// is an East Asian wide character or emoji.
+func nextGlyph(runes []rune) (n, width int) {
+	if runes[0] == keyEscape {
+		var s escapeScanner
+		for n = 0; n < len(runes); n++ {
+			if s.scan(runes[n]); n > 0 && s.state == scanText {
+				return n + 1, 0
+			}
+		}
+		return len(runes), 0
+	}
+
+	if r := runes[0]; r >= ' ' && r < 0x7f && (len(runes) == 1 || runes[1] < 0x300) {
+		// Printable ASCII not followed by a combining mark, the
+		// common case.
+		return 1, 1
+	}
+
+	end := 1
+	for end < len(runes) && end < maxGraphemeRunes && runes[end] != keyEscape {
+		end++
+	}
+	cluster, _, width, _ := uniseg.FirstGraphemeClusterInString(string(runes[:end]), -1)
+	return utf8.RuneCountInString(cluster), width
+}
+
+// States of an escapeScanner.
+const (
+	scanText = iota
+	scanEscape
+	scanCSI
+	scanOSC
+	scanOSCEscape
+)
+
+// escapeScanner finds the escape sequences in text scanned a rune at a time.
+// Most sequences end with a letter. Operating system commands, such as OSC 8
+// hyperlinks, start with ESC ] and end with BEL or ESC \.
+type escapeScanner struct {
+	state int
+}
+
+// scan returns true if r is part of an escape sequence.
+func (s *escapeScanner) scan(r rune) bool {
+	isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	switch s.state {
+	case scanText:
+		if r != keyEscape {
+			return false
+		}
+		s.state = scanEscape
+	case scanEscape:
+		switch {
+		case r == ']':
+			s.state = scanOSC
+		case isLetter:
+			s.state = scanText
+		default:
+			s.state = scanCSI
+		}
+	case scanCSI:
+		if isLetter {
+			s.state = scanText
+		}
+	case scanOSC:
+		if r == '\a' {
+			s.state = scanText
+		} else if r == keyEscape {
+			s.state = scanOSCEscape
+		}
+	case scanOSCEscape:
+		s.state = scanText
+	}
+	return true
+}
+
+// glyphPos returns the cursor position after writing a glyph of the given
+// width at x, y. A glyph that doesn't fit on the rest of the row starts on
+// the next one, as writeLine pads the row; a full row moves the cursor to the
+// start of the next one, as advanceCursor does.
+func (t *Terminal) glyphPos(x, y, width int) (int, int) {
+	if x > 0 && x+width > t.termWidth {
+		x, y = 0, y+1
+	}
+	x += width
+	return x % t.termWidth, y + x/t.termWidth
+}
+
+// layout returns the cursor position after writing runes at x, y.
+func (t *Terminal) layout(x, y int, runes []rune) (int, int) {
+	for len(runes) > 0 {
+		n, width := nextGlyph(runes)
+		x, y = t.glyphPos(x, y, width)
+		runes = runes[n:]
+	}
+	return x, y
+}
+
+// fitGlyphs returns the number of runes at the start of line that fit in
+// the given number of columns, stopping at a newline, and their width.
+func fitGlyphs(line []rune, columns int) (n, width int) {
+	for n < len(line) && line[n] != '\n' {
+		m, w := nextGlyph(line[n:])
+		if width+w > columns {
+			break
+		}
+		n += m
+		width += w
+	}
+	return n, width
+}
+
+// cellsBetween returns the number of cells from the screen position x1, y1
+// to x2, y2.
+func (t *Terminal) cellsBetween(x1, y1, x2, y2 int) int {
+	return (y2-y1)*t.termWidth + x2 - x1
+}
+
+// graphemeBefore returns the number of runes of the grapheme cluster that
+// ends at pos, so that the cursor moves over and deletes whole characters.
+func (t *Terminal) graphemeBefore(pos int) int {
+	start := t.lineStart(pos)
+	if pos-start > maxGraphemeRunes {
+		start = pos - maxGraphemeRunes
+	}
+	n := 0
+	for i := start; i < pos; i += n {
+		n, _ = nextGlyph(t.line[i:pos])
+	}
+	if n == 0 && pos > 0 {
+		n = 1
+	}
+	return n
+}
+
+// graphemeAfter returns the number of runes of the grapheme cluster that
+// starts at pos.
+func (t *Terminal) graphemeAfter(pos int) int {
+	if pos >= len(t.line) {
+		return 0
+	}
+	n, _ := nextGlyph(t.line[pos:])
+	return n
+}
+
+// runeWidth returns the width in columns of r on its own.
+func runeWidth(r rune) int {
+	if r >= ' ' && r < 0x7f {
+		return 1
+	}
+	return uniseg.StringWidth(string(r))
+}
+
+// StringWidth returns the number of columns s takes on the terminal. Escape
+// sequences, such as styles, take no space.
+func StringWidth(s string) int {
+	return visualLength([]rune(s))
+}
+
+// FitString returns the longest prefix of s that fits in the given number of
+// columns, stopping at a newline, and its width. Grapheme clusters are never
+// split.
+func FitString(s string, columns int) (prefix string, width int) {
+	runes := []rune(s)
+	n, width := fitGlyphs(runes, columns)
+	return string(runes[:n]), width
+}
@@ -18,3 +18,15 @@ func CheckEnvironmentForRendering() error {
 	}
 	return nil
 }
+
+// LocaleIsUTF8 returns true if the locale in the environment looked up with getenv uses UTF-8. The first of LC_ALL,
+// LC_CTYPE and LANG that is set decides, as for the C library.
+func LocaleIsUTF8(getenv func(string) string) bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if val := getenv(name); val != "" {
+			val = strings.ToLower(val)
+			return strings.Contains(val, "utf-8") || strings.Contains(val, "utf8")
+		}
+	}
+	return false
+}
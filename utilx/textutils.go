@@ -49,8 +49,17 @@ func Rpad(s string, padding int) string {
 
 // Tmpl executes the given template text on data, writing the result to w.
 func Tmpl(w io.Writer, text string, data interface{}) error {
+	return TmplFuncs(w, text, data, nil)
+}
+
+// TmplFuncs executes the given template text on data, writing the result to w. The funcs are available to the
+// template in addition to the default template funcs.
+func TmplFuncs(w io.Writer, text string, data interface{}, funcs template.FuncMap) error {
 	t := template.New("top")
 	t.Funcs(templateFuncs)
+	if funcs != nil {
+		t.Funcs(funcs)
+	}
 	template.Must(t.Parse(text))
 	return t.Execute(w, data)
 }